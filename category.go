@@ -0,0 +1,95 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// tagSelectorPrefix marks an active recipe selector that activates every recipe with a tag
+const tagSelectorPrefix = "tag:"
+
+// Category groups the recipes of a package under a name
+// This mirrors the CategoryDescriptor from the Java version. The tags of a category apply to all of its recipes.
+type Category struct {
+	Name        string   `yaml:"name"`
+	PackageName string   `yaml:"packageName"`
+	Description string   `yaml:"description,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+
+	// position is where the category is declared, for validation issues
+	position configPosition
+}
+
+// contains reports whether a recipe package is the package of the category or one of its subpackages
+func (c *Category) contains(pkg string) bool {
+	return pkg == c.PackageName || strings.HasPrefix(pkg, c.PackageName+".")
+}
+
+// categoryOf returns the category with the most specific package containing a recipe package, or nil
+func categoryOf(categories []Category, pkg string) *Category {
+	var found *Category
+	for i := range categories {
+		category := &categories[i]
+		if category.PackageName == "" || !category.contains(pkg) {
+			continue
+		}
+		if found == nil || len(category.PackageName) > len(found.PackageName) {
+			found = category
+		}
+	}
+	return found
+}
+
+// hasTag reports whether a recipe or its category is tagged with tag, ignoring case
+func (a *AvailableRecipe) hasTag(tag string) bool {
+	tags := a.Tags
+	if a.Category != nil {
+		tags = append(append([]string(nil), tags...), a.Category.Tags...)
+	}
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// inCategory reports whether a recipe is in the named category, or in the package or a subpackage of it
+func (a *AvailableRecipe) inCategory(name string) bool {
+	if a.Category != nil && strings.EqualFold(a.Category.Name, name) {
+		return true
+	}
+	pkg := strings.ToLower(a.Package())
+	name = strings.ToLower(name)
+	return pkg == name || strings.HasPrefix(pkg, name+".")
+}
+
+// isRecipeGlob reports whether an active recipe selector is a glob such as org.openrewrite.text.*
+func isRecipeGlob(selector string) bool {
+	return strings.ContainsAny(selector, "*?[")
+}
+
+// selectRecipes returns the names of the recipes an active recipe selector refers to
+// A selector is a tag selector such as tag:security, a glob such as org.openrewrite.text.* or a recipe name.
+// Tag selectors and globs match available recipes in name order; a recipe name is returned as is.
+func selectRecipes(selector string, available []*AvailableRecipe) []string {
+	var names []string
+	switch {
+	case strings.HasPrefix(selector, tagSelectorPrefix):
+		tag := strings.TrimPrefix(selector, tagSelectorPrefix)
+		for _, recipe := range available {
+			if !recipe.Unavailable && recipe.hasTag(tag) {
+				names = append(names, recipe.Name)
+			}
+		}
+	case isRecipeGlob(selector):
+		for _, recipe := range available {
+			if matched, _ := path.Match(selector, recipe.Name); matched && !recipe.Unavailable {
+				names = append(names, recipe.Name)
+			}
+		}
+	default:
+		names = append(names, selector)
+	}
+	return names
+}
@@ -5,70 +5,124 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 )
 
 // Config represents the configuration for the rewrite tool
 // This mirrors the ConfigurableRewriteMojo class from the Java version
 type Config struct {
 	// ConfigLocation is the path to rewrite.yml configuration file
-	ConfigLocation string `yaml:"configLocation" mapstructure:"config-location"`
+	ConfigLocation string `yaml:"configLocation" json:"configLocation" mapstructure:"config-location"`
 
 	// ActiveRecipes is the list of recipes to activate
-	ActiveRecipes []string `yaml:"activeRecipes" mapstructure:"active-recipes"`
+	ActiveRecipes []string `yaml:"activeRecipes" json:"activeRecipes" mapstructure:"active-recipes"`
 
 	// ActiveStyles is the list of styles to activate
-	ActiveStyles []string `yaml:"activeStyles" mapstructure:"active-styles"`
+	ActiveStyles []string `yaml:"activeStyles" json:"activeStyles" mapstructure:"active-styles"`
 
 	// PomCacheEnabled determines if POM caching is enabled
-	PomCacheEnabled bool `yaml:"pomCacheEnabled" mapstructure:"pom-cache-enabled"`
+	PomCacheEnabled bool `yaml:"pomCacheEnabled" json:"pomCacheEnabled" mapstructure:"pom-cache-enabled"`
 
 	// PomCacheDirectory is the directory for POM cache
-	PomCacheDirectory string `yaml:"pomCacheDirectory" mapstructure:"pom-cache-directory"`
+	PomCacheDirectory string `yaml:"pomCacheDirectory" json:"pomCacheDirectory" mapstructure:"pom-cache-directory"`
 
 	// Skip determines if rewrite execution should be skipped
-	Skip bool `yaml:"skip" mapstructure:"skip"`
+	Skip bool `yaml:"skip" json:"skip" mapstructure:"skip"`
 
 	// SkipMavenParsing skips parsing Maven pom.xml files
-	SkipMavenParsing bool `yaml:"skipMavenParsing" mapstructure:"skip-maven-parsing"`
+	SkipMavenParsing bool `yaml:"skipMavenParsing" json:"skipMavenParsing" mapstructure:"skip-maven-parsing"`
 
 	// CheckstyleConfigFile is the path to checkstyle configuration
-	CheckstyleConfigFile string `yaml:"checkstyleConfigFile" mapstructure:"checkstyle-config-file"`
+	CheckstyleConfigFile string `yaml:"checkstyleConfigFile" json:"checkstyleConfigFile" mapstructure:"checkstyle-config-file"`
 
 	// CheckstyleDetectionEnabled enables automatic checkstyle detection
-	CheckstyleDetectionEnabled bool `yaml:"checkstyleDetectionEnabled" mapstructure:"checkstyle-detection-enabled"`
+	CheckstyleDetectionEnabled bool `yaml:"checkstyleDetectionEnabled" json:"checkstyleDetectionEnabled" mapstructure:"checkstyle-detection-enabled"`
 
 	// Exclusions are file patterns to exclude from processing
-	Exclusions []string `yaml:"exclusions" mapstructure:"exclusions"`
+	Exclusions []string `yaml:"exclusions" json:"exclusions" mapstructure:"exclusions"`
 
 	// PlainTextMasks are patterns for plain text files
-	PlainTextMasks []string `yaml:"plainTextMasks" mapstructure:"plain-text-masks"`
+	PlainTextMasks []string `yaml:"plainTextMasks" json:"plainTextMasks" mapstructure:"plain-text-masks"`
 
 	// AdditionalPlainTextMasks are additional patterns for plain text files
-	AdditionalPlainTextMasks []string `yaml:"additionalPlainTextMasks" mapstructure:"additional-plain-text-masks"`
+	AdditionalPlainTextMasks []string `yaml:"additionalPlainTextMasks" json:"additionalPlainTextMasks" mapstructure:"additional-plain-text-masks"`
 
 	// SizeThresholdMb is the size threshold in MB for processing files
-	SizeThresholdMb int `yaml:"sizeThresholdMb" mapstructure:"size-threshold-mb"`
+	SizeThresholdMb int `yaml:"sizeThresholdMb" json:"sizeThresholdMb" mapstructure:"size-threshold-mb"`
 
 	// FailOnInvalidActiveRecipes determines if invalid recipes should fail the execution
-	FailOnInvalidActiveRecipes bool `yaml:"failOnInvalidActiveRecipes" mapstructure:"fail-on-invalid-active-recipes"`
+	FailOnInvalidActiveRecipes bool `yaml:"failOnInvalidActiveRecipes" json:"failOnInvalidActiveRecipes" mapstructure:"fail-on-invalid-active-recipes"`
 
 	// RunPerSubmodule determines if execution should run per submodule
-	RunPerSubmodule bool `yaml:"runPerSubmodule" mapstructure:"run-per-submodule"`
+	RunPerSubmodule bool `yaml:"runPerSubmodule" json:"runPerSubmodule" mapstructure:"run-per-submodule"`
 
 	// RecipeArtifactCoordinates are Maven coordinates for recipe artifacts
-	RecipeArtifactCoordinates []string `yaml:"recipeArtifactCoordinates" mapstructure:"recipe-artifact-coordinates"`
+	RecipeArtifactCoordinates []string `yaml:"recipeArtifactCoordinates" json:"recipeArtifactCoordinates" mapstructure:"recipe-artifact-coordinates"`
 
 	// ResolvePropertiesInYaml determines if properties should be resolved in YAML
-	ResolvePropertiesInYaml bool `yaml:"resolvePropertiesInYaml" mapstructure:"resolve-properties-in-yaml"`
+	ResolvePropertiesInYaml bool `yaml:"resolvePropertiesInYaml" json:"resolvePropertiesInYaml" mapstructure:"resolve-properties-in-yaml"`
 
-	// LogLevel for the rewrite execution
-	LogLevel string `yaml:"logLevel" mapstructure:"log-level"`
+	// LogLevel for the rewrite execution: debug, info, warn or error
+	LogLevel string `yaml:"logLevel" json:"logLevel" mapstructure:"log-level"`
+
+	// LogFormat is the format of the logs written to stderr: text or json
+	LogFormat string `yaml:"logFormat" json:"logFormat" mapstructure:"log-format"`
+
+	// Progress determines how the progress of long runs is shown: auto, bar, log or none
+	Progress string `yaml:"progress" json:"progress" mapstructure:"progress"`
 
 	// ExportDatatables determines if datatables should be exported
-	ExportDatatables bool `yaml:"exportDatatables" mapstructure:"export-datatables"`
+	ExportDatatables bool `yaml:"exportDatatables" json:"exportDatatables" mapstructure:"export-datatables"`
+
+	// FailOnDryRunResults determines if a dry run that would make changes should fail
+	FailOnDryRunResults bool `yaml:"failOnDryRunResults" json:"failOnDryRunResults" mapstructure:"fail-on-dry-run-results"`
+
+	// ReportOutputDirectory is the directory dry-run reports such as rewrite.patch are written to
+	// Defaults to target/rewrite under the build root
+	ReportOutputDirectory string `yaml:"reportOutputDirectory" json:"reportOutputDirectory" mapstructure:"report-output-directory"`
+
+	// Reports are the structured reports to write, each in the form format=path
+	Reports []string `yaml:"reports" json:"reports" mapstructure:"reports"`
+
+	// ConflictPolicy determines what happens when a file changed on disk while it was being processed
+	// Either "fail" to abort the whole run or "skip" to leave the file untouched and report the conflict
+	ConflictPolicy string `yaml:"conflictPolicy" json:"conflictPolicy" mapstructure:"conflict-policy"`
+
+	// Parallelism is the number of files processed concurrently
+	// Zero or less uses GOMAXPROCS. Profile processes one file at a time.
+	Parallelism int `yaml:"parallelism" json:"parallelism" mapstructure:"parallelism"`
+
+	// Profile records allocations per recipe and parser and logs the slowest ones at the end of a run
+	Profile bool `yaml:"profile" json:"profile" mapstructure:"profile"`
+
+	// ProfileTop is the number of recipes and parsers the profile shows
+	ProfileTop int `yaml:"profileTop" json:"profileTop" mapstructure:"profile-top"`
+
+	// RecipeErrorPolicy determines what happens when a recipe fails on a source file
+	// "fail-fast" stops processing at the first error, "continue-and-report" processes every file and
+	// reports all errors without applying any change, and "continue-and-apply-successful" also applies
+	// the changes to the files that had no error
+	RecipeErrorPolicy string `yaml:"recipeErrorPolicy" json:"recipeErrorPolicy" mapstructure:"recipe-error-policy"`
+
+	// Timeout stops the run like an interrupt once it expires, zero means no timeout
+	Timeout time.Duration `yaml:"timeout" json:"timeout" mapstructure:"timeout"`
 }
 
+// Conflict policies for files that were modified concurrently
+const (
+	ConflictPolicyFail = "fail"
+	ConflictPolicySkip = "skip"
+)
+
+// Recipe error policies for recipes that fail on a source file
+const (
+	RecipeErrorPolicyFailFast          = "fail-fast"
+	RecipeErrorPolicyContinueAndReport = "continue-and-report"
+	RecipeErrorPolicyContinueAndApply  = "continue-and-apply-successful"
+)
+
 // NewDefaultConfig creates a new Config with default values
 // This mirrors the default values from the Java Maven plugin
 func NewDefaultConfig() *Config {
@@ -81,7 +135,12 @@ func NewDefaultConfig() *Config {
 		RunPerSubmodule:            false,
 		ResolvePropertiesInYaml:    true,
 		LogLevel:                   "info",
+		LogFormat:                  LogFormatText,
+		Progress:                   ProgressAuto,
 		ExportDatatables:           false,
+		ConflictPolicy:             ConflictPolicyFail,
+		RecipeErrorPolicy:          RecipeErrorPolicyContinueAndReport,
+		ProfileTop:                 defaultProfileTop,
 		PlainTextMasks:             getDefaultPlainTextMasks(),
 	}
 }
@@ -178,6 +237,18 @@ func (c *Config) GetPlainTextMasks() []string {
 	return masks
 }
 
+// GetReportOutputDirectory resolves the directory reports are written to
+// This mirrors the output path logic from AbstractRewriteDryRunMojo
+func (c *Config) GetReportOutputDirectory(buildRoot string) string {
+	if c.ReportOutputDirectory == "" {
+		return filepath.Join(buildRoot, "target", "rewrite")
+	}
+	if filepath.IsAbs(c.ReportOutputDirectory) {
+		return c.ReportOutputDirectory
+	}
+	return filepath.Join(buildRoot, c.ReportOutputDirectory)
+}
+
 // CleanStringSlice removes empty and whitespace-only strings from a slice
 // This mirrors the getCleanedSet() method from ConfigurableRewriteMojo
 func CleanStringSlice(input []string) []string {
@@ -214,3 +285,33 @@ func (c *Config) GetExclusions() []string {
 func (c *Config) GetRecipeArtifactCoordinates() []string {
 	return CleanStringSlice(c.RecipeArtifactCoordinates)
 }
+
+// GetParallelism returns the number of files to process concurrently
+// Profiling processes one file at a time, as allocations can only be counted for the whole process.
+func (c *Config) GetParallelism() int {
+	if c.Profile {
+		return 1
+	}
+	if c.Parallelism > 0 {
+		return c.Parallelism
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// failFast reports whether processing stops at the first recipe error
+func (c *Config) failFast() (bool, error) {
+	switch c.RecipeErrorPolicy {
+	case RecipeErrorPolicyFailFast:
+		return true, nil
+	case RecipeErrorPolicyContinueAndReport, RecipeErrorPolicyContinueAndApply, "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown recipe error policy %q, expected %s, %s or %s", c.RecipeErrorPolicy,
+			RecipeErrorPolicyFailFast, RecipeErrorPolicyContinueAndReport, RecipeErrorPolicyContinueAndApply)
+	}
+}
+
+// ApplySuccessfulChanges reports whether changes are applied to the files that had no recipe error
+func (c *Config) ApplySuccessfulChanges() bool {
+	return c.RecipeErrorPolicy == RecipeErrorPolicyContinueAndApply
+}
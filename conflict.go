@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Conflict describes a file that was modified on disk after it was read
+type Conflict struct {
+	Path   string
+	Reason string
+}
+
+// Error implements the error interface
+func (c *Conflict) Error() string {
+	return fmt.Sprintf("%s: %s", c.Path, c.Reason)
+}
+
+// checksum returns the hex encoded SHA-256 hash of content
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// detectConflict checks whether the files touched by result still look the way they did when they were read
+// It returns nil if the result can safely be written.
+func detectConflict(buildRoot string, result Result) *Conflict {
+	if result.Before == nil {
+		// A generated file must not have been created by someone else in the meantime
+		if result.After != nil {
+			if _, err := os.Lstat(filepath.Join(buildRoot, result.After.Path)); err == nil {
+				return &Conflict{Path: result.After.Path, Reason: "file was created by another process"}
+			}
+		}
+		return nil
+	}
+
+	if result.Before.Checksum == "" {
+		// Nothing was recorded when the file was read, so there is nothing to compare against
+		return nil
+	}
+
+	filePath := filepath.Join(buildRoot, result.Before.Path)
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return &Conflict{Path: result.Before.Path, Reason: "file was deleted by another process"}
+	} else if err != nil {
+		return &Conflict{Path: result.Before.Path, Reason: fmt.Sprintf("failed to stat file: %v", err)}
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return &Conflict{Path: result.Before.Path, Reason: fmt.Sprintf("failed to read file: %v", err)}
+	}
+
+	// A touched file with identical content is not a conflict
+	if checksum(content) != result.Before.Checksum {
+		return &Conflict{
+			Path: result.Before.Path,
+			Reason: fmt.Sprintf("file was modified by another process (read at %s, modified at %s)",
+				result.Before.ModTime.Format(time.RFC3339), info.ModTime().Format(time.RFC3339)),
+		}
+	}
+
+	// A moved file must not overwrite a file someone else created at its destination
+	if result.After != nil && result.After.Path != result.Before.Path {
+		if _, err := os.Lstat(filepath.Join(buildRoot, result.After.Path)); err == nil {
+			return &Conflict{Path: result.After.Path, Reason: "move destination was created by another process"}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// DataTableColumn describes a column of a data table
+type DataTableColumn struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// DataTableDescriptor describes a data table and its columns
+type DataTableDescriptor struct {
+	Name        string            `json:"name"`
+	DisplayName string            `json:"displayName,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Columns     []DataTableColumn `json:"columns"`
+}
+
+// DataTable is a table of typed rows that recipes emit while visiting source files
+// This mirrors the DataTable class from the Java version. The columns are taken from the
+// exported fields of Row, which are described with struct tags:
+//
+//	type DependencyRow struct {
+//		GroupID string `column:"groupId" displayName:"Group" description:"The first part of a dependency coordinate."`
+//	}
+//
+// Fields without a column tag use the field name.
+type DataTable[Row any] struct {
+	descriptor DataTableDescriptor
+}
+
+// NewDataTable creates a data table whose columns are described by the fields of Row
+func NewDataTable[Row any](name, displayName, description string) *DataTable[Row] {
+	rowType := reflect.TypeOf((*Row)(nil)).Elem()
+	if rowType.Kind() != reflect.Struct {
+		panic("data table rows must be structs: " + name)
+	}
+
+	descriptor := DataTableDescriptor{Name: name, DisplayName: displayName, Description: description}
+	for i := 0; i < rowType.NumField(); i++ {
+		field := rowType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		column := DataTableColumn{
+			Name:        field.Tag.Get("column"),
+			DisplayName: field.Tag.Get("displayName"),
+			Description: field.Tag.Get("description"),
+		}
+		if column.Name == "" {
+			column.Name = field.Name
+		}
+		descriptor.Columns = append(descriptor.Columns, column)
+	}
+
+	return &DataTable[Row]{descriptor: descriptor}
+}
+
+// Descriptor returns the description of the table and its columns
+func (t *DataTable[Row]) Descriptor() DataTableDescriptor {
+	return t.descriptor
+}
+
+// InsertRow adds a row to the table of the run the context belongs to
+func (t *DataTable[Row]) InsertRow(ctx *ExecutionContext, row Row) {
+	value := reflect.ValueOf(row)
+	var cells []string
+	for i := 0; i < value.NumField(); i++ {
+		if !value.Type().Field(i).IsExported() {
+			continue
+		}
+		cells = append(cells, fmt.Sprint(value.Field(i).Interface()))
+	}
+	ctx.DataTables.insert(t.descriptor, cells)
+}
+
+// DataTableRows holds the rows a run collected for a data table
+type DataTableRows struct {
+	Descriptor DataTableDescriptor
+	Rows       [][]string
+}
+
+// DataTableStore collects the rows of every data table across all source files of a run
+type DataTableStore struct {
+	mu     sync.Mutex
+	tables map[string]*DataTableRows
+	order  []string
+}
+
+// NewDataTableStore creates an empty data table store
+func NewDataTableStore() *DataTableStore {
+	return &DataTableStore{tables: make(map[string]*DataTableRows)}
+}
+
+// insert adds a row to the named table, creating the table on first use
+func (s *DataTableStore) insert(descriptor DataTableDescriptor, cells []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, ok := s.tables[descriptor.Name]
+	if !ok {
+		table = &DataTableRows{Descriptor: descriptor}
+		s.tables[descriptor.Name] = table
+		s.order = append(s.order, descriptor.Name)
+	}
+	table.Rows = append(table.Rows, cells)
+}
+
+// merge appends the rows of another store, adding its tables in the order they were first used
+func (s *DataTableStore) merge(other *DataTableStore) {
+	for _, table := range other.Tables() {
+		for _, row := range table.Rows {
+			s.insert(table.Descriptor, row)
+		}
+	}
+}
+
+// Tables returns the tables that received rows, in the order they were first used
+func (s *DataTableStore) Tables() []*DataTableRows {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tables := make([]*DataTableRows, 0, len(s.order))
+	for _, name := range s.order {
+		tables = append(tables, s.tables[name])
+	}
+	return tables
+}
+
+// SourcesFileResultsRow is a row of the table listing every file a recipe changed
+type SourcesFileResultsRow struct {
+	SourcePath          string  `column:"sourcePath" displayName:"Source path before the run" description:"The source path of the file before the run."`
+	AfterSourcePath     string  `column:"afterSourcePath" displayName:"Source path after the run" description:"A recipe may modify the source path. This is the path after the run."`
+	Recipe              string  `column:"recipe" displayName:"Recipe that made changes" description:"The specific recipe that made a change."`
+	EstimatedTimeSaving float64 `column:"estimatedTimeSaving" displayName:"Estimated time saving" description:"An estimated effort that a developer would have spent to fix the issue, in seconds."`
+}
+
+// sourcesFileResults is filled by the rewriter for every changed file
+var sourcesFileResults = NewDataTable[SourcesFileResultsRow](
+	"org.openrewrite.table.SourcesFileResults",
+	"Source files that had results",
+	"Source files that were modified by the recipe run.",
+)
+
+// exportDataTables writes every data table of a run as CSV when ExportDatatables is enabled
+// Each table is written to <table name>.csv in a directory per run, next to a columns.csv
+// file describing the columns of all tables.
+func (r *Runner) exportDataTables(buildRoot string, results *ResultsContainer) error {
+	if !r.Rewriter.Config.ExportDatatables || results.DataTables == nil {
+		return nil
+	}
+
+	tables := results.DataTables.Tables()
+	if len(tables) == 0 {
+		r.Logger.Info("No data tables were produced")
+		return nil
+	}
+
+	dir := filepath.Join(r.Rewriter.Config.GetReportOutputDirectory(buildRoot), "datatables", time.Now().Format("2006-01-02_15-04-05.000"))
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create data table directory: %w", err)
+	}
+
+	columns := [][]string{{"table", "column", "displayName", "description"}}
+	for _, table := range tables {
+		header := make([]string, 0, len(table.Descriptor.Columns))
+		for _, column := range table.Descriptor.Columns {
+			header = append(header, column.Name)
+			columns = append(columns, []string{table.Descriptor.Name, column.Name, column.DisplayName, column.Description})
+		}
+
+		err = writeCSVFile(filepath.Join(dir, table.Descriptor.Name+".csv"), append([][]string{header}, table.Rows...))
+		if err != nil {
+			return fmt.Errorf("failed to export data table %s: %w", table.Descriptor.Name, err)
+		}
+		r.Logger.Info("Exported data table", "table", table.Descriptor.Name, "rows", len(table.Rows))
+	}
+
+	err = writeCSVFile(filepath.Join(dir, "columns.csv"), columns)
+	if err != nil {
+		return fmt.Errorf("failed to export data table columns: %w", err)
+	}
+
+	r.Logger.Info("Data tables available", "dir", dir)
+	return nil
+}
+
+// writeCSVFile writes records to a CSV file
+func writeCSVFile(path string, records [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	err = writer.WriteAll(records)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffOp is the kind of a line in a diff
+type DiffOp byte
+
+const (
+	DiffEqual  DiffOp = ' '
+	DiffDelete DiffOp = '-'
+	DiffInsert DiffOp = '+'
+)
+
+// DiffLine is a single line of a diff
+// Text includes the line terminator, so the last line of a file without a trailing newline has none.
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// Hunk is a group of changed lines with their surrounding context
+// Line numbers are 1-based, as in unified diffs.
+type Hunk struct {
+	OldStart int
+	OldCount int
+	NewStart int
+	NewCount int
+	Lines    []DiffLine
+}
+
+// DiffStats counts the lines added and removed by a diff
+type DiffStats struct {
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+}
+
+// splitLines splits text into lines, keeping the line terminators
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// DiffText computes the line-based difference between two texts
+func DiffText(before, after string) []DiffLine {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	// Common prefix and suffix are trimmed first to keep the search space small
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []DiffLine
+	for _, line := range a[:prefix] {
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: line})
+	}
+	lines = append(lines, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: line})
+	}
+
+	return lines
+}
+
+// maxEditDistance bounds the work spent on a single diff
+// Texts that differ in more lines than this are diffed as a full replacement.
+const maxEditDistance = 2000
+
+// myersDiff computes a shortest edit script between a and b using Myers' algorithm
+func myersDiff(a, b []string) []DiffLine {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	max := n + m
+	if max > maxEditDistance {
+		max = maxEditDistance
+	}
+	offset := max + 1
+	v := make([]int, 2*max+2)
+
+	// trace[d] holds the furthest reaching x of every diagonal k in [-d, d] before step d
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrackDiff(a, b, trace, d)
+			}
+		}
+	}
+
+	// Too many differences to search for a minimal diff
+	var lines []DiffLine
+	for _, line := range a {
+		lines = append(lines, DiffLine{Op: DiffDelete, Text: line})
+	}
+	for _, line := range b {
+		lines = append(lines, DiffLine{Op: DiffInsert, Text: line})
+	}
+	return lines
+}
+
+// backtrackDiff walks the recorded search trace backwards to build the edit script
+func backtrackDiff(a, b []string, trace [][]int, depth int) []DiffLine {
+	x, y := len(a), len(b)
+	var reversed []DiffLine
+
+	for d := depth; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[d+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, DiffLine{Op: DiffEqual, Text: a[x]})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, DiffLine{Op: DiffInsert, Text: b[y]})
+		} else {
+			x--
+			reversed = append(reversed, DiffLine{Op: DiffDelete, Text: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, DiffLine{Op: DiffEqual, Text: a[x]})
+	}
+
+	lines := make([]DiffLine, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines
+}
+
+// Hunks groups the changed lines of a diff into hunks with the given number of context lines
+func Hunks(lines []DiffLine, context int) []Hunk {
+	// Line numbers of both sides at every line of the diff
+	oldLines := make([]int, len(lines))
+	newLines := make([]int, len(lines))
+	var changes []int
+
+	oldLine, newLine := 1, 1
+	for i, line := range lines {
+		oldLines[i], newLines[i] = oldLine, newLine
+		switch line.Op {
+		case DiffEqual:
+			oldLine++
+			newLine++
+		case DiffDelete:
+			oldLine++
+			changes = append(changes, i)
+		case DiffInsert:
+			newLine++
+			changes = append(changes, i)
+		}
+	}
+
+	var hunks []Hunk
+	for i := 0; i < len(changes); {
+		// Changes separated by no more than twice the context end up in the same hunk
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j]-1 <= 2*context {
+			j++
+		}
+
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[j] + context + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		hunk := Hunk{OldStart: oldLines[start], NewStart: newLines[start], Lines: lines[start:end]}
+		for _, line := range hunk.Lines {
+			if line.Op != DiffInsert {
+				hunk.OldCount++
+			}
+			if line.Op != DiffDelete {
+				hunk.NewCount++
+			}
+		}
+
+		// By convention an empty range starts at the line before it
+		if hunk.OldCount == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewCount == 0 {
+			hunk.NewStart--
+		}
+
+		hunks = append(hunks, hunk)
+		i = j + 1
+	}
+
+	return hunks
+}
+
+// Header returns the unified diff range header of the hunk
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldCount), hunkRange(h.NewStart, h.NewCount))
+}
+
+// String returns the hunk as it appears in a unified diff, its header followed by its lines
+func (h Hunk) String() string {
+	var sb strings.Builder
+	sb.WriteString(h.Header())
+	sb.WriteString("\n")
+	for _, line := range h.Lines {
+		sb.WriteByte(byte(line.Op))
+		sb.WriteString(line.Text)
+		if !strings.HasSuffix(line.Text, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+	return sb.String()
+}
+
+// hunkRange formats a unified diff range, omitting the count when it is one
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Stats counts the added and removed lines of a diff
+func Stats(lines []DiffLine) DiffStats {
+	var stats DiffStats
+	for _, line := range lines {
+		switch line.Op {
+		case DiffInsert:
+			stats.Additions++
+		case DiffDelete:
+			stats.Deletions++
+		}
+	}
+	return stats
+}
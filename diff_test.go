@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sidesOf rebuilds both texts of a diff
+func sidesOf(lines []DiffLine) (before, after string) {
+	var b, a strings.Builder
+	for _, line := range lines {
+		if line.Op != DiffInsert {
+			b.WriteString(line.Text)
+		}
+		if line.Op != DiffDelete {
+			a.WriteString(line.Text)
+		}
+	}
+	return b.String(), a.String()
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b
+func lcsLength(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
+
+func TestDiffText(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []DiffLine
+	}{
+		{name: "both empty", before: "", after: "", want: nil},
+		{name: "unchanged", before: "a\n", after: "a\n", want: []DiffLine{{DiffEqual, "a\n"}}},
+		{name: "new file", before: "", after: "a\nb\n", want: []DiffLine{{DiffInsert, "a\n"}, {DiffInsert, "b\n"}}},
+		{name: "emptied file", before: "a\nb\n", after: "", want: []DiffLine{{DiffDelete, "a\n"}, {DiffDelete, "b\n"}}},
+		{
+			name:   "changed line",
+			before: "a\nb\nc\n",
+			after:  "a\nx\nc\n",
+			want:   []DiffLine{{DiffEqual, "a\n"}, {DiffDelete, "b\n"}, {DiffInsert, "x\n"}, {DiffEqual, "c\n"}},
+		},
+		{
+			name:   "no trailing newline",
+			before: "a\nb",
+			after:  "a\nc",
+			want:   []DiffLine{{DiffEqual, "a\n"}, {DiffDelete, "b"}, {DiffInsert, "c"}},
+		},
+		{
+			name:   "trailing newline added",
+			before: "a",
+			after:  "a\n",
+			want:   []DiffLine{{DiffDelete, "a"}, {DiffInsert, "a\n"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffText(tt.before, tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffTextIsMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		var a, b []string
+		for j := random.Intn(12); j > 0; j-- {
+			a = append(a, fmt.Sprintf("%c\n", 'a'+random.Intn(4)))
+		}
+		for j := random.Intn(12); j > 0; j-- {
+			b = append(b, fmt.Sprintf("%c\n", 'a'+random.Intn(4)))
+		}
+		before, after := strings.Join(a, ""), strings.Join(b, "")
+
+		lines := DiffText(before, after)
+		gotBefore, gotAfter := sidesOf(lines)
+		if gotBefore != before || gotAfter != after {
+			t.Fatalf("DiffText(%q, %q) does not rebuild both texts", before, after)
+		}
+		stats := Stats(lines)
+		if want := len(a) + len(b) - 2*lcsLength(a, b); stats.Additions+stats.Deletions != want {
+			t.Fatalf("DiffText(%q, %q) has %d changes, want %d", before, after, stats.Additions+stats.Deletions, want)
+		}
+	}
+}
+
+func TestMyersDiffFallsBackBeyondMaxEditDistance(t *testing.T) {
+	// A minimal diff would keep the shared line, but it is further than maxEditDistance edits away
+	var a, b []string
+	for i := 0; i < maxEditDistance; i++ {
+		if i == maxEditDistance/2 {
+			a = append(a, "shared\n")
+			b = append(b, "shared\n")
+		}
+		a = append(a, fmt.Sprintf("old %d\n", i))
+		b = append(b, fmt.Sprintf("new %d\n", i))
+	}
+
+	lines := myersDiff(a, b)
+	if len(lines) != len(a)+len(b) {
+		t.Fatalf("myersDiff() has %d lines, want %d", len(lines), len(a)+len(b))
+	}
+	for i, line := range lines {
+		want := DiffDelete
+		if i >= len(a) {
+			want = DiffInsert
+		}
+		if line.Op != want {
+			t.Fatalf("line %d is %c, want a full replacement", i, line.Op)
+		}
+	}
+	before, after := sidesOf(lines)
+	if before != strings.Join(a, "") || after != strings.Join(b, "") {
+		t.Fatal("the replacement does not rebuild both texts")
+	}
+}
+
+// numberedLines returns the lines "1\n" to "n\n"
+func numberedLines(n int) []string {
+	var lines []string
+	for i := 1; i <= n; i++ {
+		lines = append(lines, fmt.Sprintf("%d\n", i))
+	}
+	return lines
+}
+
+// replaceLines returns lines with the given 1-based lines changed
+func replaceLines(lines []string, changed ...int) []string {
+	result := append([]string(nil), lines...)
+	for _, line := range changed {
+		result[line-1] = "changed\n"
+	}
+	return result
+}
+
+// hunkHeaders returns the headers of the hunks of a diff
+func hunkHeaders(before, after string, context int) []string {
+	var headers []string
+	for _, hunk := range Hunks(DiffText(before, after), context) {
+		headers = append(headers, hunk.Header())
+	}
+	return headers
+}
+
+func TestHunks(t *testing.T) {
+	lines := numberedLines(20)
+	text := strings.Join(lines, "")
+
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []string
+	}{
+		{name: "no changes", before: text, after: text, want: nil},
+		{name: "single change", before: text, after: strings.Join(replaceLines(lines, 10), ""), want: []string{"@@ -7,7 +7,7 @@"}},
+		{name: "context clipped at start", before: text, after: strings.Join(replaceLines(lines, 1), ""), want: []string{"@@ -1,4 +1,4 @@"}},
+		{name: "context clipped at end", before: text, after: strings.Join(replaceLines(lines, 20), ""), want: []string{"@@ -17,4 +17,4 @@"}},
+		// Changes separated by up to twice the context share a hunk
+		{name: "merged", before: text, after: strings.Join(replaceLines(lines, 5, 11), ""), want: []string{"@@ -2,13 +2,13 @@"}},
+		{name: "merged at twice the context", before: text, after: strings.Join(replaceLines(lines, 5, 12), ""), want: []string{"@@ -2,14 +2,14 @@"}},
+		{name: "separate", before: text, after: strings.Join(replaceLines(lines, 5, 13), ""), want: []string{"@@ -2,7 +2,7 @@", "@@ -10,7 +10,7 @@"}},
+		{name: "new file", before: "", after: "a\nb\n", want: []string{"@@ -0,0 +1,2 @@"}},
+		{name: "emptied file", before: "a\nb\n", after: "", want: []string{"@@ -1,2 +0,0 @@"}},
+		{name: "single line", before: "a\n", after: "b\n", want: []string{"@@ -1 +1 @@"}},
+		{name: "insertion", before: strings.Join(lines[:6], ""), after: strings.Join(lines[:3], "") + "new\n" + strings.Join(lines[3:6], ""), want: []string{"@@ -1,6 +1,7 @@"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hunkHeaders(tt.before, tt.after, 3)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHunksWithoutContext(t *testing.T) {
+	lines := numberedLines(10)
+	got := hunkHeaders(strings.Join(lines, ""), strings.Join(replaceLines(lines, 2, 3, 7), ""), 0)
+	want := []string{"@@ -2,2 +2,2 @@", "@@ -7 +7 @@"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Hunks() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// discoverIndent is the indentation of each level of the discover output
+const discoverIndent = "    "
+
+// AvailableRecipe describes a recipe that can be activated, built in or declared in the configuration
+// This mirrors the RecipeDescriptor of the Java version as listed by the discover goal.
+type AvailableRecipe struct {
+	Name        string
+	DisplayName string
+	Description string
+	Tags        []string
+	Options     []RecipeOption
+	RecipeList  []RecipeListEntry
+
+	// Category is the declared category the recipe is in, if any
+	Category *Category
+
+	// Declared is set for declarative recipes from the configuration
+	Declared bool
+	// Unavailable is set for recipes the configuration refers to that rewrite-go does not implement
+	Unavailable bool
+}
+
+// Package returns the package the name of the recipe is in
+func (a *AvailableRecipe) Package() string {
+	if i := strings.LastIndex(a.Name, "."); i > 0 {
+		return a.Name[:i]
+	}
+	return a.Name
+}
+
+// AvailableRecipes returns every built-in recipe and every recipe of the configuration, ordered by name
+// Declarative recipes take precedence over built-in recipes of the same name, as they do when running.
+// Recipes the configuration activates that are neither built in nor declared are marked unavailable.
+func (r *Rewriter) AvailableRecipes() []*AvailableRecipe {
+	return availableRecipes(r.Environment)
+}
+
+// availableRecipes returns the available recipes of an environment, which may be nil
+func availableRecipes(env *Environment) []*AvailableRecipe {
+	recipes := map[string]*AvailableRecipe{}
+	for _, descriptor := range RegisteredRecipes() {
+		recipes[descriptor.Name] = &AvailableRecipe{
+			Name:        descriptor.Name,
+			DisplayName: descriptor.DisplayName,
+			Description: descriptor.Description,
+			Tags:        descriptor.Tags,
+			Options:     descriptor.Options,
+		}
+	}
+
+	if env != nil {
+		for _, recipe := range env.Recipes {
+			if len(recipe.RecipeList) > 0 || recipe.DisplayName != "" || recipe.Description != "" {
+				recipes[recipe.Name] = &AvailableRecipe{
+					Name:        recipe.Name,
+					DisplayName: recipe.DisplayName,
+					Description: recipe.Description,
+					Tags:        recipe.Tags,
+					RecipeList:  recipe.RecipeList,
+					Declared:    true,
+				}
+			} else if recipes[recipe.Name] == nil {
+				recipes[recipe.Name] = &AvailableRecipe{Name: recipe.Name, Unavailable: true}
+			}
+		}
+	}
+
+	sorted := make([]*AvailableRecipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		if env != nil {
+			recipe.Category = categoryOf(env.Categories, recipe.Package())
+		}
+		sorted = append(sorted, recipe)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].Name) < strings.ToLower(sorted[j].Name)
+	})
+	return sorted
+}
+
+// findAvailableRecipe looks up an available recipe by name, ignoring case
+func findAvailableRecipe(recipes []*AvailableRecipe, name string) *AvailableRecipe {
+	for _, recipe := range recipes {
+		if recipe.Name == name {
+			return recipe
+		}
+	}
+	for _, recipe := range recipes {
+		if strings.EqualFold(recipe.Name, name) {
+			return recipe
+		}
+	}
+	return nil
+}
+
+// DiscoverOptions control what the discover command shows
+type DiscoverOptions struct {
+	// Recipe is the name of a single recipe to describe, matched ignoring case
+	Recipe string
+	// Detail shows the display name, description, tags and options of every recipe
+	Detail bool
+	// Recursion is the depth up to which the recipe lists of recipes are expanded
+	Recursion int
+	// Tag lists only the recipes tagged with it, directly or through their category
+	Tag string
+	// Category lists only the recipes of a category, or of a package and its subpackages
+	Category string
+	// Search lists the recipes matching a query, best matches first
+	Search string
+	// JSON writes the results of a search as JSON
+	JSON bool
+}
+
+// matches reports whether a recipe passes the tag and category filters of the options
+func (o DiscoverOptions) matches(recipe *AvailableRecipe) bool {
+	if o.Tag != "" && !recipe.hasTag(o.Tag) {
+		return false
+	}
+	return o.Category == "" || recipe.inCategory(o.Category)
+}
+
+// categoryHeader returns the heading under which a recipe is listed: its category or else its package
+func categoryHeader(recipe *AvailableRecipe) string {
+	if recipe.Category != nil && recipe.Category.Name != "" {
+		return recipe.Category.Name
+	}
+	return recipe.Package()
+}
+
+// Discover lists the available recipes grouped by category, or describes a single recipe
+// This mirrors the RewriteDiscoverMojo. The listing is written to stdout. Recipes are grouped under
+// their declared category, or under their package if no category contains it.
+func (r *Runner) Discover(options DiscoverOptions) error {
+	err := r.Rewriter.LoadEnvironment()
+	if err != nil {
+		return configError(fmt.Errorf("failed to load environment: %w", err))
+	}
+
+	if options.JSON && options.Search == "" {
+		return configError(fmt.Errorf("--json requires --search"))
+	}
+
+	recipes := r.Rewriter.AvailableRecipes()
+	if options.Search != "" {
+		var candidates []*AvailableRecipe
+		for _, recipe := range recipes {
+			if options.matches(recipe) {
+				candidates = append(candidates, recipe)
+			}
+		}
+		results := searchRecipes(candidates, options.Search)
+		if options.JSON {
+			return writeRecipeSearchResultsJSON(os.Stdout, results)
+		}
+		writeRecipeSearchResults(os.Stdout, options.Search, results)
+		return nil
+	}
+
+	if options.Recipe != "" {
+		recipe := findAvailableRecipe(recipes, options.Recipe)
+		if recipe == nil {
+			return configError(fmt.Errorf("could not find recipe '%s' among available recipes", options.Recipe))
+		}
+		// A single recipe is always described in detail
+		options.Detail = true
+		writeAvailableRecipe(os.Stdout, recipes, recipe, nil, options, 0, 0)
+		return nil
+	}
+
+	env := r.Rewriter.Environment
+	w := os.Stdout
+
+	groups := map[string][]*AvailableRecipe{}
+	var headers []string
+	listed := 0
+	for _, recipe := range recipes {
+		if !options.matches(recipe) {
+			continue
+		}
+		header := categoryHeader(recipe)
+		if groups[header] == nil {
+			headers = append(headers, header)
+		}
+		groups[header] = append(groups[header], recipe)
+		listed++
+	}
+	sort.Slice(headers, func(i, j int) bool {
+		return strings.ToLower(headers[i]) < strings.ToLower(headers[j])
+	})
+
+	fmt.Fprintln(w, "Available Recipes:")
+	for _, header := range headers {
+		fmt.Fprintf(w, "%s%s:\n", discoverIndent, header)
+		if category := groups[header][0].Category; options.Detail && category != nil && category.Description != "" {
+			fmt.Fprintf(w, "%s%s\n", strings.Repeat(discoverIndent, 2), category.Description)
+		}
+		for _, recipe := range groups[header] {
+			writeAvailableRecipe(w, recipes, recipe, nil, options, 0, 2)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Available Styles:")
+	availableStyles := knownStyleNames(env)
+	for _, style := range availableStyles {
+		fmt.Fprintf(w, "%s%s\n", discoverIndent, style)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Active Styles:")
+	for _, style := range sortedStyleNames(env.ActiveStyles) {
+		fmt.Fprintf(w, "%s%s\n", discoverIndent, style)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Active Recipes:")
+	for _, active := range env.ActiveRecipes {
+		recipe := findAvailableRecipe(recipes, active.Name)
+		if recipe == nil {
+			recipe = &AvailableRecipe{Name: active.Name, Unavailable: true}
+		}
+		writeAvailableRecipe(w, recipes, recipe, active.Options, options, 0, 1)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Found %d available recipes and %d available styles.\n", listed, len(availableStyles))
+	fmt.Fprintf(w, "Configured with %d active recipes and %d active styles.\n", len(env.ActiveRecipes), len(env.ActiveStyles))
+	return nil
+}
+
+// writeAvailableRecipe writes a recipe and, up to the recursion depth, the recipes of its recipe list
+// values are the options the recipe is configured with where it is referenced, if any.
+func writeAvailableRecipe(w io.Writer, recipes []*AvailableRecipe, recipe *AvailableRecipe, values map[string]interface{}, options DiscoverOptions, level, indentLevel int) {
+	indent := strings.Repeat(discoverIndent, indentLevel)
+
+	if !options.Detail {
+		fmt.Fprintf(w, "%s%s%s\n", indent, recipe.Name, unavailableNote(recipe))
+	} else {
+		if recipe.DisplayName != "" {
+			fmt.Fprintf(w, "%s%s\n", indent, recipe.DisplayName)
+		}
+		fmt.Fprintf(w, "%s%s%s%s\n", indent, discoverIndent, recipe.Name, unavailableNote(recipe))
+		if recipe.Description != "" {
+			fmt.Fprintf(w, "%s%s%s\n", indent, discoverIndent, recipe.Description)
+		}
+		if len(recipe.Tags) > 0 {
+			fmt.Fprintf(w, "%s%stags: %s\n", indent, discoverIndent, strings.Join(recipe.Tags, ", "))
+		}
+
+		if len(recipe.Options) > 0 {
+			fmt.Fprintf(w, "%soptions:\n", indent)
+			for _, option := range recipe.Options {
+				required := ""
+				if option.Required {
+					required = "!"
+				}
+				fmt.Fprintf(w, "%s%s%s: %s%s\n", indent, discoverIndent, option.Name, option.Type, required)
+				if option.Description != "" {
+					fmt.Fprintf(w, "%s%s%s%s\n", indent, discoverIndent, discoverIndent, option.Description)
+				}
+				// An empty default is no default worth showing
+				if option.Default != nil && option.Default != "" {
+					fmt.Fprintf(w, "%s%s%sdefault: %v\n", indent, discoverIndent, discoverIndent, option.Default)
+				}
+				if option.Example != "" {
+					fmt.Fprintf(w, "%s%s%sexample: %s\n", indent, discoverIndent, discoverIndent, option.Example)
+				}
+			}
+		}
+	}
+
+	if len(values) > 0 {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s%s%s = %v\n", indent, discoverIndent, name, values[name])
+		}
+	}
+
+	if len(recipe.RecipeList) > 0 && level+1 <= options.Recursion {
+		fmt.Fprintf(w, "%srecipeList:\n", indent)
+		for _, entry := range recipe.RecipeList {
+			sub := findAvailableRecipe(recipes, entry.Name)
+			if sub == nil {
+				sub = &AvailableRecipe{Name: entry.Name, Unavailable: true}
+			}
+			writeAvailableRecipe(w, recipes, sub, entry.Options, options, level+1, indentLevel+1)
+		}
+	}
+
+	if options.Detail {
+		fmt.Fprintln(w)
+	}
+}
+
+// unavailableNote returns a note for recipes rewrite-go does not implement
+func unavailableNote(recipe *AvailableRecipe) string {
+	if recipe.Unavailable {
+		return " (not available in rewrite-go)"
+	}
+	return ""
+}
+
+// sortedStyleNames returns the names of styles ordered ignoring case
+func sortedStyleNames(styles []Style) []string {
+	names := make([]string, 0, len(styles))
+	for _, style := range styles {
+		names = append(names, style.Name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
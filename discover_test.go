@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what f writes to stdout
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		content, _ := io.ReadAll(reader)
+		output <- string(content)
+	}()
+	f()
+	writer.Close()
+	return <-output
+}
+
+func TestDiscoverUnresolvableActiveRecipe(t *testing.T) {
+	for _, detail := range []bool{false, true} {
+		config := &Config{ActiveRecipes: []string{"org.Typo"}, LogLevel: "error"}
+		runner := NewRunner(NewRewriter(config, t.TempDir()))
+
+		var err error
+		output := captureStdout(t, func() {
+			err = runner.Discover(DiscoverOptions{Detail: detail})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, active, _ := strings.Cut(output, "Active Recipes:\n")
+		if !strings.Contains(active, "org.Typo (not available in rewrite-go)") {
+			t.Errorf("Discover(detail %v) does not list org.Typo as unavailable:\n%s", detail, output)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes returned by rewrite-go, so pipelines can tell failures apart
+const (
+	// ExitOK means the command completed successfully
+	ExitOK = 0
+	// ExitFailure means the command failed for any other reason, e.g. an I/O error
+	ExitFailure = 1
+	// ExitConfigError means the configuration, a flag or rewrite.yml is invalid
+	ExitConfigError = 2
+	// ExitRecipeError means a recipe produced an error
+	ExitRecipeError = 3
+	// ExitChangesFound means a dry run found changes and failOnDryRunResults is set
+	ExitChangesFound = 4
+	// ExitInterrupted means the run was stopped by SIGINT or SIGTERM, as is conventional for shells
+	ExitInterrupted = 130
+)
+
+// ErrChangesFound is returned by a dry run that would make changes when failOnDryRunResults is set
+var ErrChangesFound = errors.New("applying recipes would make changes. See logs for more details")
+
+// exitCodeError associates an error with the exit code of the process
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+// Error implements the error interface
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// configError marks err as caused by invalid configuration
+func configError(err error) error {
+	return &exitCodeError{code: ExitConfigError, err: err}
+}
+
+// recipeError marks err as produced by a recipe
+func recipeError(err error) error {
+	return &exitCodeError{code: ExitRecipeError, err: err}
+}
+
+// recipeErrors returns the error of a run in which recipes produced errs
+func recipeErrors(errs []error) error {
+	if len(errs) == 1 {
+		return recipeError(errs[0])
+	}
+	return recipeError(fmt.Errorf("recipes produced %d errors, the first: %w", len(errs), errs[0]))
+}
+
+// exitCode returns the process exit code for err
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, ErrChangesFound) {
+		return ExitChangesFound
+	}
+	if errors.Is(err, ErrInterrupted) {
+		return ExitInterrupted
+	}
+
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	return ExitFailure
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runHistoryRoot is the directory, relative to the build root, holding the tool's own state
+const runHistoryRoot = ".rewrite"
+
+// Change types recorded in a run journal
+const (
+	changeGenerated  = "generated"
+	changeDeleted    = "deleted"
+	changeMoved      = "moved"
+	changeRefactored = "refactored"
+)
+
+// RunRecord is the persisted journal of a run
+// It holds everything needed to restore the tree to the state it was in before the run.
+type RunRecord struct {
+	ID        string           `json:"id"`
+	StartedAt time.Time        `json:"startedAt"`
+	Recipes   []string         `json:"recipes,omitempty"`
+	Changes   []RecordedChange `json:"changes"`
+	UndoneAt  *time.Time       `json:"undoneAt,omitempty"`
+
+	// Incomplete is set while the run applies its changes, when Changes holds every planned change
+	// The record is saved before the first file is written, so an applied change is never without its
+	// before-state, and finalized once the changes are committed.
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+// RecordedChange is a single change made by a run
+type RecordedChange struct {
+	Type          string      `json:"type"`
+	BeforePath    string      `json:"beforePath,omitempty"`
+	AfterPath     string      `json:"afterPath,omitempty"`
+	BeforeMode    os.FileMode `json:"beforeMode,omitempty"`
+	BeforeFile    string      `json:"beforeFile,omitempty"`
+	AfterChecksum string      `json:"afterChecksum,omitempty"`
+
+	// beforeContent is written to BeforeFile when the record is saved
+	beforeContent string
+}
+
+// NewRunRecord creates an empty record for a run starting now
+func NewRunRecord(recipes []string) *RunRecord {
+	now := time.Now().UTC()
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+
+	return &RunRecord{
+		ID:        now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix),
+		StartedAt: now,
+		Recipes:   recipes,
+	}
+}
+
+// Add records the change described by result
+func (rr *RunRecord) Add(changeType string, result Result) {
+	change := RecordedChange{Type: changeType}
+	if result.Before != nil {
+		change.BeforePath = result.Before.Path
+		change.BeforeMode = result.Before.Mode
+		change.beforeContent = result.Before.Content
+	}
+	if result.After != nil {
+		change.AfterPath = result.After.Path
+		change.AfterChecksum = checksum([]byte(result.After.Content))
+	}
+	rr.Changes = append(rr.Changes, change)
+}
+
+// AddResults records every change of the results, in the order they are applied
+func (rr *RunRecord) AddResults(results *ResultsContainer) {
+	for _, result := range results.Generated {
+		if result.After != nil {
+			rr.Add(changeGenerated, result)
+		}
+	}
+	for _, result := range results.Deleted {
+		if result.Before != nil {
+			rr.Add(changeDeleted, result)
+		}
+	}
+	for _, result := range results.Moved {
+		if result.Before != nil && result.After != nil {
+			rr.Add(changeMoved, result)
+		}
+	}
+	for _, result := range results.RefactoredInPlace {
+		if result.After != nil {
+			rr.Add(changeRefactored, result)
+		}
+	}
+}
+
+// Finalize saves the record of a run whose changes are committed, with only the changes of the results
+// Planned changes that were skipped since the record was saved are dropped with their before-state.
+func (rr *RunRecord) Finalize(buildRoot string, results *ResultsContainer) error {
+	applied := &RunRecord{}
+	applied.AddResults(results)
+
+	key := func(change RecordedChange) string {
+		return change.Type + "\x00" + change.BeforePath + "\x00" + change.AfterPath
+	}
+	kept := map[string]bool{}
+	for _, change := range applied.Changes {
+		kept[key(change)] = true
+	}
+
+	var changes []RecordedChange
+	for _, change := range rr.Changes {
+		if kept[key(change)] {
+			changes = append(changes, change)
+		} else if change.BeforeFile != "" {
+			removeExisting(filepath.Join(runHistoryDir(buildRoot), rr.ID, "files", change.BeforeFile))
+		}
+	}
+	rr.Changes = changes
+	rr.Incomplete = false
+	return rr.Save(buildRoot)
+}
+
+// Delete removes the record and the before-state it saved
+func (rr *RunRecord) Delete(buildRoot string) error {
+	err := os.RemoveAll(filepath.Join(runHistoryDir(buildRoot), rr.ID))
+	if err != nil {
+		return fmt.Errorf("failed to remove run %s: %w", rr.ID, err)
+	}
+	return nil
+}
+
+// removeRolledBackRun removes the record of a run whose changes were rolled back by recovery
+// The record is saved before the journal is started, so the interrupted run is the most recent one,
+// and it is only removed if it was not finalized.
+func removeRolledBackRun(buildRoot string) error {
+	records, err := LoadRunRecords(buildRoot)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 || !records[len(records)-1].Incomplete {
+		return nil
+	}
+	return records[len(records)-1].Delete(buildRoot)
+}
+
+// runHistoryDir returns the directory holding all run records of a build root
+func runHistoryDir(buildRoot string) string {
+	return filepath.Join(buildRoot, runHistoryRoot, "runs")
+}
+
+// Save persists the record and the before-state of every change under the build root
+func (rr *RunRecord) Save(buildRoot string) error {
+	runDir := filepath.Join(runHistoryDir(buildRoot), rr.ID)
+	filesDir := filepath.Join(runDir, "files")
+	err := os.MkdirAll(filesDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create run directory %s: %w", runDir, err)
+	}
+
+	// Keep the run history out of version control
+	ignoreFile := filepath.Join(buildRoot, runHistoryRoot, ".gitignore")
+	if _, err := os.Stat(ignoreFile); os.IsNotExist(err) {
+		err = os.WriteFile(ignoreFile, []byte("*\n"), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", ignoreFile, err)
+		}
+	}
+
+	for i := range rr.Changes {
+		change := &rr.Changes[i]
+		if change.BeforePath == "" || change.BeforeFile != "" {
+			continue
+		}
+		change.BeforeFile = fmt.Sprintf("%06d", i)
+		err = os.WriteFile(filepath.Join(filesDir, change.BeforeFile), []byte(change.beforeContent), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to save contents of %s: %w", change.BeforePath, err)
+		}
+	}
+
+	content, err := json.MarshalIndent(rr, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run record: %w", err)
+	}
+
+	// The record is written last so a run directory without it is never mistaken for a complete run
+	staged, err := tempName(runDir, ".rewrite-tmp-")
+	if err != nil {
+		return err
+	}
+	err = stageFile(staged, content, 0644)
+	if err != nil {
+		removeExisting(staged)
+		return err
+	}
+	err = os.Rename(staged, filepath.Join(runDir, "run.json"))
+	if err != nil {
+		removeExisting(staged)
+		return fmt.Errorf("failed to save run record: %w", err)
+	}
+
+	return nil
+}
+
+// beforeContent returns the saved before-state of change
+func (rr *RunRecord) beforeContent(buildRoot string, change RecordedChange) ([]byte, error) {
+	path := filepath.Join(runHistoryDir(buildRoot), rr.ID, "files", change.BeforeFile)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved contents of %s: %w", change.BeforePath, err)
+	}
+	return content, nil
+}
+
+// LoadRunRecords loads all run records of a build root, oldest first
+func LoadRunRecords(buildRoot string) ([]*RunRecord, error) {
+	entries, err := os.ReadDir(runHistoryDir(buildRoot))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+
+	var records []*RunRecord
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(runHistoryDir(buildRoot), entry.Name(), "run.json"))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read run %s: %w", entry.Name(), err)
+		}
+
+		var record RunRecord
+		err = json.Unmarshal(content, &record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse run %s: %w", entry.Name(), err)
+		}
+		records = append(records, &record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+
+	return records, nil
+}
+
+// History lists the previous runs of the project
+func (r *Runner) History() error {
+	buildRoot, err := r.Rewriter.GetBuildRoot()
+	if err != nil {
+		return fmt.Errorf("failed to get build root: %w", err)
+	}
+
+	records, err := LoadRunRecords(buildRoot)
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No runs recorded")
+		return nil
+	}
+
+	fmt.Printf("%-22s  %-20s  %-8s  %7s  %s\n", "RUN", "STARTED", "STATUS", "CHANGES", "RECIPES")
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		status := "applied"
+		if record.UndoneAt != nil {
+			status = "undone"
+		} else if record.Incomplete {
+			status = "incomplete"
+		}
+		fmt.Printf("%-22s  %-20s  %-8s  %7d  %s\n", record.ID, record.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			status, len(record.Changes), strings.Join(record.Recipes, ", "))
+	}
+
+	return nil
+}
+
+// Undo restores the tree to the state it was in before the given run
+// If runID is empty, the most recent run that has not been undone yet is restored.
+// Unless force is set, the run is only undone if none of the files it touched changed since.
+func (r *Runner) Undo(runID string, force bool) error {
+	buildRoot, err := r.Rewriter.GetBuildRoot()
+	if err != nil {
+		return fmt.Errorf("failed to get build root: %w", err)
+	}
+	err = r.recoverInterruptedRun(buildRoot)
+	if err != nil {
+		return err
+	}
+
+	records, err := LoadRunRecords(buildRoot)
+	if err != nil {
+		return err
+	}
+
+	var record *RunRecord
+	for i := len(records) - 1; i >= 0; i-- {
+		if runID == "" && records[i].UndoneAt == nil || runID != "" && records[i].ID == runID {
+			record = records[i]
+			break
+		}
+	}
+	if record == nil {
+		if runID == "" {
+			return fmt.Errorf("no run to undo")
+		}
+		return fmt.Errorf("run %s not found", runID)
+	}
+	if record.UndoneAt != nil {
+		return fmt.Errorf("run %s has already been undone at %s", record.ID, record.UndoneAt.Local().Format(time.RFC3339))
+	}
+
+	if !force {
+		conflicts := detectUndoConflicts(buildRoot, record)
+		if len(conflicts) > 0 {
+			r.Logger.Warn("Files changed since the run", "run", record.ID)
+			for _, conflict := range conflicts {
+				r.Logger.Warn("File changed since the run", "path", conflict.Path, "reason", conflict.Reason)
+			}
+			return fmt.Errorf("cannot undo run %s: %d files changed since, use --force to overwrite them", record.ID, len(conflicts))
+		}
+	}
+
+	r.Logger.Info("Undoing run", "run", record.ID, "changes", len(record.Changes))
+
+	tx := NewTransaction(transactionJournal(buildRoot))
+	err = r.stageUndo(tx, buildRoot, record)
+	if err == nil {
+		err = r.commitTransaction(tx)
+	}
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		r.Logger.Info("All changes have been rolled back")
+		return err
+	}
+
+	// Remove directories that only existed because of files the run created
+	for _, change := range record.Changes {
+		if change.AfterPath != "" && change.AfterPath != change.BeforePath {
+			removeEmptyParents(buildRoot, filepath.Join(buildRoot, change.AfterPath))
+		}
+	}
+
+	now := time.Now().UTC()
+	record.UndoneAt = &now
+	err = record.Save(buildRoot)
+	if err != nil {
+		r.Logger.Warn("Failed to mark run as undone", "run", record.ID, "error", err)
+	}
+
+	r.Logger.Info("Run has been undone", "run", record.ID)
+	return nil
+}
+
+// stageUndo reverts every change of the record within the given transaction, newest first
+func (r *Runner) stageUndo(tx *Transaction, buildRoot string, record *RunRecord) error {
+	for i := len(record.Changes) - 1; i >= 0; i-- {
+		change := record.Changes[i]
+
+		if change.AfterPath != "" && change.AfterPath != change.BeforePath {
+			err := tx.Remove(filepath.Join(buildRoot, change.AfterPath))
+			if err != nil {
+				return fmt.Errorf("failed to remove %s: %w", change.AfterPath, err)
+			}
+		}
+
+		if change.BeforePath != "" {
+			content, err := record.beforeContent(buildRoot, change)
+			if err != nil {
+				return err
+			}
+			mode := change.BeforeMode
+			if mode == 0 {
+				mode = 0644
+			}
+			err = tx.WriteFile(filepath.Join(buildRoot, change.BeforePath), content, mode)
+			if err != nil {
+				return fmt.Errorf("failed to restore %s: %w", change.BeforePath, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// detectUndoConflicts returns the files of a run that no longer look the way the run left them
+func detectUndoConflicts(buildRoot string, record *RunRecord) []Conflict {
+	var conflicts []Conflict
+
+	for _, change := range record.Changes {
+		if change.AfterPath != "" {
+			content, err := os.ReadFile(filepath.Join(buildRoot, change.AfterPath))
+			if os.IsNotExist(err) {
+				conflicts = append(conflicts, Conflict{Path: change.AfterPath, Reason: "file has been deleted"})
+			} else if err != nil {
+				conflicts = append(conflicts, Conflict{Path: change.AfterPath, Reason: fmt.Sprintf("failed to read file: %v", err)})
+			} else if checksum(content) != change.AfterChecksum {
+				conflicts = append(conflicts, Conflict{Path: change.AfterPath, Reason: "file has been modified"})
+			}
+		}
+
+		if change.BeforePath != "" && change.BeforePath != change.AfterPath {
+			if _, err := os.Lstat(filepath.Join(buildRoot, change.BeforePath)); err == nil {
+				conflicts = append(conflicts, Conflict{Path: change.BeforePath, Reason: "file has been recreated"})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// removeEmptyParents removes the empty directories between path and the build root
+func removeEmptyParents(buildRoot, path string) {
+	for dir := filepath.Dir(path); dir != buildRoot && strings.HasPrefix(dir, buildRoot); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
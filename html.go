@@ -0,0 +1,393 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// htmlFile is a changed file as rendered in the HTML report
+type htmlFile struct {
+	ID        int
+	Category  string
+	Path      string
+	Module    string
+	Recipes   []string
+	TimeSaved string
+	DiffStats
+	Rows []htmlDiffRow
+}
+
+// htmlDiffRow is a row of a side-by-side diff
+// A row without line numbers on either side separates two hunks.
+type htmlDiffRow struct {
+	OldLine, NewLine int
+	Old, New         template.HTML
+	OldOp, NewOp     string
+	Separator        bool
+}
+
+// htmlGroup is a recipe or module with the files it changed
+type htmlGroup struct {
+	Name        string
+	DisplayName string
+	TimeSaved   string
+	Files       []*htmlFile
+}
+
+// htmlReport is the data the HTML report template is rendered with
+type htmlReport struct {
+	Report     *RunReport
+	StartedAt  string
+	TimeSaved  string
+	Categories []string
+	Files      []*htmlFile
+	ByRecipe   []*htmlGroup
+	ByModule   []*htmlGroup
+}
+
+// writeHTMLReport writes the results of a run as a single self-contained HTML page
+func writeHTMLReport(w io.Writer, report *RunReport) error {
+	data := &htmlReport{
+		Report:     report,
+		StartedAt:  report.StartedAt.Local().Format("2006-01-02 15:04:05"),
+		TimeSaved:  formatDuration(time.Duration(report.Summary.TimeSavedSeconds * float64(time.Second))),
+		Categories: []string{changeGenerated, changeDeleted, changeMoved, changeRefactored},
+	}
+
+	recipes := map[string]*htmlGroup{}
+	modules := map[string]*htmlGroup{}
+	moduleCache := map[string]string{}
+
+	results := report.Container
+	categories := []struct {
+		name    string
+		results []Result
+	}{
+		{changeGenerated, results.Generated},
+		{changeDeleted, results.Deleted},
+		{changeMoved, results.Moved},
+		{changeRefactored, results.RefactoredInPlace},
+	}
+	for _, category := range categories {
+		for _, result := range category.results {
+			file := newHTMLFile(len(data.Files)+1, category.name, result)
+			modulePath := file.Path
+			if result.Before != nil {
+				modulePath = result.Before.Path
+			} else if result.After != nil {
+				modulePath = result.After.Path
+			}
+			file.Module = moduleOf(report.ProjectRoot, modulePath, moduleCache)
+			data.Files = append(data.Files, file)
+
+			for _, recipe := range file.Recipes {
+				if recipes[recipe] == nil {
+					recipes[recipe] = &htmlGroup{Name: recipe, DisplayName: describeRecipe(report.Environment, recipe).DisplayName}
+				}
+				recipes[recipe].Files = append(recipes[recipe].Files, file)
+			}
+			if modules[file.Module] == nil {
+				modules[file.Module] = &htmlGroup{Name: file.Module}
+			}
+			modules[file.Module].Files = append(modules[file.Module].Files, file)
+		}
+	}
+
+	data.ByRecipe = sortedGroups(recipes)
+	data.ByModule = sortedGroups(modules)
+
+	seconds := func(s float64) string { return formatDuration(time.Duration(s * float64(time.Second))) }
+	for _, recipe := range report.Recipes {
+		if group := recipes[recipe.Name]; group != nil {
+			group.TimeSaved = seconds(recipe.TimeSavedSeconds)
+		}
+	}
+	for _, module := range report.Modules {
+		if group := modules[module.Module]; group != nil {
+			group.TimeSaved = seconds(module.TimeSavedSeconds)
+		}
+	}
+
+	return htmlReportTemplate.Execute(w, data)
+}
+
+// newHTMLFile renders the side-by-side diff of a result
+func newHTMLFile(id int, category string, result Result) *htmlFile {
+	var before, after string
+	if result.Before != nil {
+		before = result.Before.Content
+	}
+	if result.After != nil {
+		after = result.After.Content
+	}
+
+	path := resultPath(result)
+	lines := DiffText(before, after)
+	file := &htmlFile{
+		ID:        id,
+		Category:  category,
+		Path:      path,
+		Recipes:   result.RecipesThatMadeChanges,
+		TimeSaved: formatDuration(result.TimeSaved),
+		DiffStats: Stats(lines),
+	}
+
+	highlighter := highlighterFor(path)
+	for i, hunk := range Hunks(lines, patchContextLines) {
+		if i > 0 {
+			file.Rows = append(file.Rows, htmlDiffRow{Separator: true})
+		}
+
+		oldLine, newLine := hunk.OldStart, hunk.NewStart
+		if hunk.OldCount == 0 {
+			oldLine++
+		}
+		if hunk.NewCount == 0 {
+			newLine++
+		}
+
+		// Deleted and inserted lines are paired up so replacements show next to each other
+		var deleted, inserted []string
+		flush := func() {
+			for j := 0; j < len(deleted) || j < len(inserted); j++ {
+				var row htmlDiffRow
+				if j < len(deleted) {
+					row.OldLine, row.Old, row.OldOp = oldLine, highlighter(deleted[j]), "del"
+					oldLine++
+				}
+				if j < len(inserted) {
+					row.NewLine, row.New, row.NewOp = newLine, highlighter(inserted[j]), "ins"
+					newLine++
+				}
+				file.Rows = append(file.Rows, row)
+			}
+			deleted, inserted = nil, nil
+		}
+
+		for _, line := range hunk.Lines {
+			text := strings.TrimRight(line.Text, "\r\n")
+			switch line.Op {
+			case DiffDelete:
+				deleted = append(deleted, text)
+			case DiffInsert:
+				inserted = append(inserted, text)
+			default:
+				flush()
+				code := highlighter(text)
+				file.Rows = append(file.Rows, htmlDiffRow{OldLine: oldLine, NewLine: newLine, Old: code, New: code})
+				oldLine++
+				newLine++
+			}
+		}
+		flush()
+	}
+
+	return file
+}
+
+// sortedGroups returns the groups ordered by name
+func sortedGroups(groups map[string]*htmlGroup) []*htmlGroup {
+	var sorted []*htmlGroup
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// Token patterns of the syntax highlighter, in order of precedence
+var (
+	hashCommentLanguages = map[string]bool{
+		".py": true, ".rb": true, ".sh": true, ".bash": true, ".ksh": true, ".yml": true, ".yaml": true,
+		".properties": true, ".toml": true, ".hcl": true, ".env": true, ".gitignore": true, ".gitattributes": true,
+	}
+	slashTokenPattern = regexp.MustCompile(`(//.*$|/\*.*?\*/|"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|` + "`[^`]*`" + `|\b\d+(?:\.\d+)?\b|\b[A-Za-z_][A-Za-z0-9_]*\b)`)
+	hashTokenPattern  = regexp.MustCompile(`(#.*$|"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|\b\d+(?:\.\d+)?\b|\b[A-Za-z_][A-Za-z0-9_]*\b)`)
+	markupPattern     = regexp.MustCompile(`(<!--.*?-->|</?[A-Za-z][\w:.-]*|/?>|"[^"]*"|'[^']*')`)
+	keywords          = map[string]bool{
+		"abstract": true, "and": true, "as": true, "async": true, "await": true, "boolean": true, "break": true,
+		"case": true, "catch": true, "class": true, "const": true, "continue": true, "def": true, "default": true,
+		"defer": true, "do": true, "elif": true, "else": true, "enum": true, "export": true, "extends": true,
+		"false": true, "final": true, "finally": true, "for": true, "from": true, "fun": true, "func": true,
+		"function": true, "go": true, "if": true, "implements": true, "import": true, "in": true, "int": true,
+		"interface": true, "is": true, "let": true, "map": true, "new": true, "nil": true, "not": true, "null": true,
+		"object": true, "or": true, "override": true, "package": true, "private": true, "protected": true,
+		"public": true, "range": true, "return": true, "static": true, "struct": true, "super": true, "switch": true,
+		"this": true, "throw": true, "throws": true, "true": true, "try": true, "type": true, "val": true, "var": true,
+		"void": true, "while": true, "with": true, "yield": true, "None": true, "True": true, "False": true,
+	}
+)
+
+// highlighterFor returns a function that escapes and highlights a line of code in the language of path
+func highlighterFor(path string) func(string) template.HTML {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".xml" || ext == ".html" || ext == ".htm" || ext == ".svg" || ext == ".jsp":
+		return func(line string) template.HTML {
+			return highlight(line, markupPattern, func(token string) string {
+				switch {
+				case strings.HasPrefix(token, "<!--"):
+					return "c"
+				case strings.HasPrefix(token, "\"") || strings.HasPrefix(token, "'"):
+					return "s"
+				default:
+					return "k"
+				}
+			})
+		}
+	case ext == ".md" || ext == ".txt" || ext == ".adoc":
+		return func(line string) template.HTML {
+			return template.HTML(html.EscapeString(line))
+		}
+	}
+
+	pattern, comment := slashTokenPattern, "/"
+	if hashCommentLanguages[ext] || hashCommentLanguages[filepath.Base(path)] {
+		pattern, comment = hashTokenPattern, "#"
+	}
+	return func(line string) template.HTML {
+		return highlight(line, pattern, func(token string) string {
+			switch {
+			case strings.HasPrefix(token, comment):
+				return "c"
+			case strings.HasPrefix(token, "\"") || strings.HasPrefix(token, "'") || strings.HasPrefix(token, "`"):
+				return "s"
+			case token[0] >= '0' && token[0] <= '9':
+				return "n"
+			case keywords[token]:
+				return "k"
+			default:
+				return ""
+			}
+		})
+	}
+}
+
+// highlight escapes line and wraps the tokens matched by pattern in spans of the class chosen by classify
+func highlight(line string, pattern *regexp.Regexp, classify func(string) string) template.HTML {
+	var sb strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(line, -1) {
+		token := line[match[0]:match[1]]
+		class := classify(token)
+		if class == "" {
+			continue
+		}
+		sb.WriteString(html.EscapeString(line[last:match[0]]))
+		fmt.Fprintf(&sb, `<span class="%s">%s</span>`, class, html.EscapeString(token))
+		last = match[1]
+	}
+	sb.WriteString(html.EscapeString(line[last:]))
+	return template.HTML(sb.String())
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Report.Tool}} {{.Report.Command}} report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #1f2328; background: #f6f8fa; }
+header { background: #24292f; color: #fff; padding: 16px 24px; }
+header h1 { margin: 0 0 4px 0; font-size: 20px; }
+header p { margin: 0; color: #d0d7de; font-size: 13px; }
+main { padding: 16px 24px; }
+.summary { display: flex; flex-wrap: wrap; gap: 12px; margin-bottom: 16px; }
+.card { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 8px 16px; min-width: 110px; }
+.card b { display: block; font-size: 22px; }
+.controls { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 8px 16px; margin-bottom: 16px; }
+.controls label { margin-right: 16px; font-size: 14px; }
+.group { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; margin-bottom: 8px; }
+.group summary { padding: 8px 16px; cursor: pointer; font-weight: 600; }
+.group ul { margin: 0; padding: 0 16px 8px 40px; }
+.group .desc { font-weight: normal; color: #57606a; }
+.file { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; margin: 16px 0; overflow: hidden; }
+.file h3 { margin: 0; padding: 8px 16px; font-size: 14px; background: #f6f8fa; border-bottom: 1px solid #d0d7de; font-family: ui-monospace, Menlo, Consolas, monospace; }
+.file .meta { padding: 4px 16px; font-size: 12px; color: #57606a; border-bottom: 1px solid #d0d7de; }
+.badge { display: inline-block; border-radius: 10px; padding: 0 8px; font-size: 12px; font-weight: 600; color: #fff; margin-right: 6px; }
+.badge.generated { background: #1a7f37; } .badge.deleted { background: #cf222e; } .badge.moved { background: #8250df; } .badge.refactored { background: #0969da; }
+.add { color: #1a7f37; } .rem { color: #cf222e; }
+table.diff { width: 100%; border-collapse: collapse; table-layout: fixed; font-family: ui-monospace, Menlo, Consolas, monospace; font-size: 12px; }
+table.diff td { padding: 0 8px; vertical-align: top; white-space: pre-wrap; word-break: break-all; }
+table.diff td.ln { width: 48px; text-align: right; color: #8c959f; user-select: none; }
+table.diff td.del { background: #ffebe9; } table.diff td.ins { background: #e6ffec; }
+table.diff tr.sep td { background: #ddf4ff; height: 8px; }
+.k { color: #cf222e; } .s { color: #0a3069; } .c { color: #6e7781; font-style: italic; } .n { color: #0550ae; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Report.Tool}} {{.Report.Command}} report</h1>
+<p>{{.Report.ProjectRoot}} &middot; {{.StartedAt}} &middot; {{.Report.Tool}} {{.Report.Version}}</p>
+</header>
+<main>
+<div class="summary">
+<div class="card"><b>{{.Report.Summary.Generated}}</b>generated</div>
+<div class="card"><b>{{.Report.Summary.Deleted}}</b>deleted</div>
+<div class="card"><b>{{.Report.Summary.Moved}}</b>moved</div>
+<div class="card"><b>{{.Report.Summary.Refactored}}</b>refactored</div>
+<div class="card"><b><span class="add">+{{.Report.Summary.Additions}}</span> <span class="rem">-{{.Report.Summary.Deletions}}</span></b>lines</div>
+<div class="card"><b>{{.TimeSaved}}</b>estimated time saved</div>
+</div>
+{{with .Report.Errors}}<div class="controls">{{range .}}<p class="rem">{{with .Path}}{{.}}: {{end}}{{with .Recipe}}{{.}}: {{end}}{{.Message}}</p>{{end}}</div>{{end}}
+<div class="controls">
+<strong>Show:</strong>
+{{range .Categories}}<label><input type="checkbox" class="category-filter" value="{{.}}" checked> {{.}}</label>{{end}}
+<strong>Group by:</strong>
+<label><input type="radio" name="grouping" value="recipe" checked> recipe</label>
+<label><input type="radio" name="grouping" value="module"> module</label>
+</div>
+<section id="by-recipe">
+{{range .ByRecipe}}<details class="group" open><summary>{{.Name}}{{with .DisplayName}} <span class="desc">&mdash; {{.}}</span>{{end}} ({{len .Files}}{{with .TimeSaved}}, {{.}} saved{{end}})</summary><ul>
+{{range .Files}}<li class="entry" data-category="{{.Category}}"><span class="badge {{.Category}}">{{.Category}}</span><a href="#file-{{.ID}}">{{.Path}}</a></li>
+{{end}}</ul></details>
+{{else}}<p>No changes.</p>{{end}}
+</section>
+<section id="by-module" class="hidden">
+{{range .ByModule}}<details class="group" open><summary>{{.Name}} ({{len .Files}}{{with .TimeSaved}}, {{.}} saved{{end}})</summary><ul>
+{{range .Files}}<li class="entry" data-category="{{.Category}}"><span class="badge {{.Category}}">{{.Category}}</span><a href="#file-{{.ID}}">{{.Path}}</a></li>
+{{end}}</ul></details>
+{{else}}<p>No changes.</p>{{end}}
+</section>
+{{range .Files}}<section class="file entry" id="file-{{.ID}}" data-category="{{.Category}}">
+<h3><span class="badge {{.Category}}">{{.Category}}</span>{{.Path}} <span class="add">+{{.Additions}}</span> <span class="rem">-{{.Deletions}}</span></h3>
+<div class="meta">module {{.Module}} &middot; {{range $i, $r := .Recipes}}{{if $i}}, {{end}}{{$r}}{{end}} &middot; estimated time saved {{.TimeSaved}}</div>
+<table class="diff">
+{{range .Rows}}{{if .Separator}}<tr class="sep"><td class="ln"></td><td></td><td class="ln"></td><td></td></tr>
+{{else}}<tr><td class="ln">{{if .OldLine}}{{.OldLine}}{{end}}</td><td class="{{.OldOp}}">{{.Old}}</td><td class="ln">{{if .NewLine}}{{.NewLine}}{{end}}</td><td class="{{.NewOp}}">{{.New}}</td></tr>
+{{end}}{{end}}</table>
+</section>
+{{end}}
+</main>
+<script>
+(function () {
+  function applyFilters() {
+    var shown = {};
+    document.querySelectorAll('.category-filter').forEach(function (box) { shown[box.value] = box.checked; });
+    document.querySelectorAll('.entry').forEach(function (el) {
+      el.classList.toggle('hidden', !shown[el.getAttribute('data-category')]);
+    });
+  }
+  document.querySelectorAll('.category-filter').forEach(function (box) { box.addEventListener('change', applyFilters); });
+  document.querySelectorAll('input[name=grouping]').forEach(function (radio) {
+    radio.addEventListener('change', function () {
+      document.getElementById('by-recipe').classList.toggle('hidden', radio.value !== 'recipe' || !radio.checked);
+      document.getElementById('by-module').classList.toggle('hidden', radio.value !== 'module' || !radio.checked);
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// checkstyleLocations are where projects usually keep their checkstyle configuration
+var checkstyleLocations = []string{
+	"checkstyle.xml",
+	filepath.Join("config", "checkstyle", "checkstyle.xml"),
+	filepath.Join("src", "main", "resources", "checkstyle.xml"),
+}
+
+// languageNames names the languages of source file extensions in the generated configuration
+var languageNames = map[string]string{
+	".java": "Java", ".kt": "Kotlin", ".groovy": "Groovy", ".scala": "Scala",
+	".js": "JavaScript", ".jsx": "JavaScript", ".ts": "TypeScript", ".tsx": "TypeScript",
+	".go": "Go", ".rs": "Rust", ".py": "Python", ".rb": "Ruby",
+	".c": "C", ".h": "C", ".cpp": "C++", ".hpp": "C++",
+	".cs": "C#", ".vb": "Visual Basic", ".php": "PHP",
+	".xml": "XML", ".json": "JSON", ".yaml": "YAML", ".yml": "YAML",
+	".properties": "Properties", ".toml": "TOML", ".hcl": "HCL",
+}
+
+// InitOptions control where and whether init writes a configuration
+type InitOptions struct {
+	// Path is the file to write, rewrite.yml in the build root if empty
+	Path string
+	// Force overwrites an existing file
+	Force bool
+}
+
+// projectDetection is what init found out about the project in the build root
+type projectDetection struct {
+	Name         string
+	Maven        bool
+	Languages    []languageCount
+	Checkstyle   string
+	EditorConfig string
+	Indentation  detectedIndentation
+}
+
+// languageCount is the number of source files of a language
+type languageCount struct {
+	Name  string
+	Files int
+}
+
+// detectedIndentation is the indentation of the project, from .editorconfig or the defaults
+type detectedIndentation struct {
+	UseTabCharacter    bool
+	TabSize            int
+	IndentSize         int
+	ContinuationIndent int
+}
+
+// Init writes a starter rewrite.yml for the project in the build root
+// It detects Maven from pom.xml, the languages of the source files, a checkstyle configuration and
+// the indentation of an .editorconfig. An existing file is only overwritten with Force.
+func (r *Runner) Init(ctx context.Context, options InitOptions) error {
+	buildRoot, err := r.Rewriter.GetBuildRoot()
+	if err != nil {
+		return fmt.Errorf("failed to get build root: %w", err)
+	}
+
+	target := options.Path
+	if target == "" {
+		target = filepath.Join(buildRoot, "rewrite.yml")
+	}
+	if _, err := os.Stat(target); err == nil && !options.Force {
+		return configError(fmt.Errorf("%s already exists, use --force to overwrite it", target))
+	}
+
+	r.Logger.Info("Inspecting project", "root", buildRoot)
+	project, err := r.detectProject(ctx, buildRoot)
+	if err != nil {
+		return err
+	}
+
+	var content strings.Builder
+	err = initTemplate.Execute(&content, project)
+	if err != nil {
+		return fmt.Errorf("failed to render configuration: %w", err)
+	}
+
+	err = os.WriteFile(target, []byte(content.String()), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	r.Logger.Info("Wrote configuration", "path", target, "recipe", project.Name+".Starter")
+	r.Logger.Info("Run rewrite-go validate to check it and rewrite-go dry-run to preview its changes.")
+	return nil
+}
+
+// detectProject inspects the build root for init
+func (r *Runner) detectProject(ctx context.Context, buildRoot string) (*projectDetection, error) {
+	project := &projectDetection{
+		Name:        projectName(buildRoot),
+		Indentation: detectedIndentation{TabSize: 4, IndentSize: 4, ContinuationIndent: 8},
+	}
+
+	if content, err := os.ReadFile(filepath.Join(buildRoot, "pom.xml")); err == nil {
+		project.Maven = true
+		var pom pomProject
+		if xml.Unmarshal(content, &pom) == nil && pom.ArtifactID != "" {
+			groupID := pom.GroupID
+			if groupID == "" {
+				groupID = pom.Parent.GroupID
+			}
+			project.Name = recipeNamePart(strings.Trim(groupID+"."+pom.ArtifactID, "."))
+		}
+	}
+
+	sourceFiles, err := r.Rewriter.FindSourceFiles(ctx, buildRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find source files: %w", err)
+	}
+	counts := map[string]int{}
+	for _, sourceFile := range sourceFiles {
+		if name, ok := languageNames[strings.ToLower(filepath.Ext(sourceFile))]; ok {
+			counts[name]++
+		}
+	}
+	for name, files := range counts {
+		project.Languages = append(project.Languages, languageCount{Name: name, Files: files})
+	}
+	sort.Slice(project.Languages, func(i, j int) bool {
+		if project.Languages[i].Files != project.Languages[j].Files {
+			return project.Languages[i].Files > project.Languages[j].Files
+		}
+		return project.Languages[i].Name < project.Languages[j].Name
+	})
+
+	locations := checkstyleLocations
+	if r.Rewriter.Config.CheckstyleConfigFile != "" {
+		locations = append([]string{r.Rewriter.Config.CheckstyleConfigFile}, locations...)
+	}
+	for _, location := range locations {
+		if _, err := os.Stat(filepath.Join(buildRoot, location)); err == nil {
+			project.Checkstyle = filepath.ToSlash(location)
+			break
+		}
+	}
+
+	editorConfig := filepath.Join(buildRoot, ".editorconfig")
+	if _, err := os.Stat(editorConfig); err == nil {
+		project.EditorConfig = ".editorconfig"
+		err = readEditorConfigIndentation(editorConfig, &project.Indentation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read .editorconfig: %w", err)
+		}
+	}
+
+	return project, nil
+}
+
+// projectName returns a recipe name prefix for a project without a pom.xml, from its directory name
+func projectName(buildRoot string) string {
+	name := recipeNamePart(filepath.Base(buildRoot))
+	if name == "" {
+		return "project"
+	}
+	return name
+}
+
+// invalidRecipeNameChars matches the characters that are not valid in a recipe name
+var invalidRecipeNameChars = regexp.MustCompile(`[^A-Za-z0-9_.]+`)
+
+// recipeNamePart replaces the characters that are not valid in a recipe name
+func recipeNamePart(name string) string {
+	return strings.Trim(invalidRecipeNameChars.ReplaceAllString(name, "_"), "_.")
+}
+
+// readEditorConfigIndentation reads the indentation of the sections of an .editorconfig that apply to all files
+// or to Java files. Later sections override earlier ones, as in EditorConfig.
+func readEditorConfigIndentation(path string, indentation *detectedIndentation) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	applies := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section := line[1 : len(line)-1]
+			applies = section == "*" || strings.Contains(section, "java")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !applies {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+		size, sizeErr := strconv.Atoi(value)
+		switch {
+		case key == "indent_style":
+			indentation.UseTabCharacter = value == "tab"
+		case key == "indent_size" && sizeErr == nil:
+			indentation.IndentSize = size
+		case key == "tab_width" && sizeErr == nil:
+			indentation.TabSize = size
+		case (key == "continuation_indent_size" || key == "ij_continuation_indent_size") && sizeErr == nil:
+			indentation.ContinuationIndent = size
+		}
+	}
+	return scanner.Err()
+}
+
+// initTemplate is the starter configuration written by init
+var initTemplate = template.Must(template.New("rewrite.yml").Parse(`# rewrite.yml for {{.Name}}, generated by rewrite-go init
+#
+# Detected:
+{{- if .Maven}}
+#   - a Maven project (pom.xml)
+{{- end}}
+{{- range .Languages}}
+#   - {{.Name}} source files: {{.Files}}
+{{- end}}
+{{- if .Checkstyle}}
+#   - a checkstyle configuration at {{.Checkstyle}}
+{{- end}}
+{{- if .EditorConfig}}
+#   - indentation from {{.EditorConfig}}
+{{- end}}
+#
+# rewrite-go discover lists the recipes you can add, rewrite-go validate checks this file and
+# rewrite-go dry-run previews the changes. rewrite-go schema > rewrite.schema.json lets editors
+# complete this file when its first line is:
+#   # yaml-language-server: $schema=rewrite.schema.json
+
+# Styles recipes format code with
+styleList:
+  - {{.Name}}.Style
+
+# Recipes declared here are run. Recipes without a declaration of their own, such as
+# org.openrewrite.text.FindAndReplace, are run by listing them under a top-level recipeList.
+recipes:
+  # A starter recipe: the recipes below only report what they find and change no files.
+  # Add the recipes your team wants to run on every build to its recipe list.
+  - name: {{.Name}}.Starter
+    displayName: Starter recipes for {{.Name}}
+    description: Finds TODO comments{{if .Maven}} and lists the Maven dependencies in use{{end}}.
+    recipeList:
+      - org.openrewrite.text.Find:
+          find: TODO
+{{- if .Maven}}
+      - org.openrewrite.maven.search.DependencyInsight:
+          groupIdPattern: "*"
+          artifactIdPattern: "*"
+{{- end}}
+      # Replace a term across the project, e.g.:
+      # - org.openrewrite.text.FindAndReplace:
+      #     find: blacklist
+      #     replace: denylist
+
+styles:
+  - name: {{.Name}}.Style
+{{- if .Checkstyle}}
+    # The checkstyle configuration at {{.Checkstyle}} may define different rules, keep them in line
+{{- end}}
+    styleConfigs:
+      - org.openrewrite.java.style.TabsAndIndentsStyle:
+          useTabCharacter: {{.Indentation.UseTabCharacter}}
+          tabSize: {{.Indentation.TabSize}}
+          indentSize: {{.Indentation.IndentSize}}
+          continuationIndent: {{.Indentation.ContinuationIndent}}
+`))
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ErrInterrupted is the cause of a run that was stopped by SIGINT or SIGTERM
+var ErrInterrupted = errors.New("interrupted")
+
+// newRunContext returns a context that is cancelled on the first SIGINT or SIGTERM, or once timeout expires
+// Cancelling stops scheduling new work; in-flight writes are finished or rolled back and a partial
+// report is written. A second signal exits the process immediately. A timeout of zero means none.
+// The returned stop function releases the signal handler.
+func newRunContext(timeout time.Duration) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+		fmt.Fprintln(os.Stderr, "Interrupted, finishing in-flight work. Press Ctrl-C again to exit immediately.")
+		cancel(ErrInterrupted)
+
+		select {
+		case <-signals:
+			fmt.Fprintln(os.Stderr, "Interrupted again, exiting immediately")
+			os.Exit(ExitInterrupted)
+		case <-done:
+		}
+	}()
+
+	stop := func() {
+		signal.Stop(signals)
+		close(done)
+		cancel(nil)
+	}
+
+	if timeout <= 0 {
+		return ctx, stop
+	}
+
+	timeoutCtx, cancelTimeout := context.WithTimeoutCause(ctx, timeout, fmt.Errorf("timed out after %s", timeout))
+	return timeoutCtx, func() {
+		cancelTimeout()
+		stop()
+	}
+}
+
+// interruption returns the reason ctx was cancelled, or nil if it was not
+func interruption(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return context.Cause(ctx)
+}
+
+// reportInterrupted reports the partial results of a run that was cancelled before every source
+// file was processed. No changes are applied, so the tree is left as it was.
+func (r *Runner) reportInterrupted(ctx context.Context, command string, startedAt time.Time, total int, results *ResultsContainer) error {
+	cause := interruption(ctx)
+	r.Logger.Warn("Run stopped, showing partial results", "reason", cause, "processed", results.FilesProcessed, "total", total)
+
+	if len(results.Errors) > 0 {
+		r.logRecipeErrors(results, total)
+	}
+	if results.IsNotEmpty() {
+		r.reportDryRunResults(results)
+	}
+	r.logSearchResults(results)
+	if command == "run" {
+		r.Logger.Info("No changes have been applied")
+	}
+
+	err := r.writeReports(command, startedAt, results)
+	if err != nil {
+		r.Logger.Warn("Failed to write reports", "error", err)
+	}
+	return cause
+}
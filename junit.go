@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// JUnit XML model, as understood by common CI test dashboards
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// writeJUnitReport writes the results of a run as JUnit XML
+// Every active recipe becomes a test suite and every file it would change becomes a
+// failing test case whose message is the first hunk the recipe changed and whose body
+// is the full diff. Recipe errors become test cases in error.
+func writeJUnitReport(w io.Writer, report *RunReport) error {
+	var names []string
+	suites := map[string]*junitTestSuite{}
+	suite := func(name string) *junitTestSuite {
+		if s, ok := suites[name]; ok {
+			return s
+		}
+		suites[name] = &junitTestSuite{Name: name, Timestamp: report.StartedAt.UTC().Format("2006-01-02T15:04:05")}
+		names = append(names, name)
+		return suites[name]
+	}
+
+	if report.Environment != nil {
+		for _, recipe := range report.Environment.ActiveRecipes {
+			suite(recipe.Name)
+		}
+	}
+
+	results := report.Container
+	categories := []struct {
+		name    string
+		results []Result
+	}{
+		{changeGenerated, results.Generated},
+		{changeDeleted, results.Deleted},
+		{changeMoved, results.Moved},
+		{changeRefactored, results.RefactoredInPlace},
+	}
+	for _, category := range categories {
+		for _, result := range category.results {
+			path := resultPath(result)
+			diff := UnifiedDiff(result)
+			messages := junitFailureMessages(result)
+			for _, recipe := range result.RecipesThatMadeChanges {
+				message, ok := messages[recipe]
+				if !ok {
+					message = fmt.Sprintf("%s would be %s", path, category.name)
+				}
+				s := suite(recipe)
+				s.TestCases = append(s.TestCases, junitTestCase{
+					Name:      path,
+					ClassName: recipe,
+					Failure: &junitProblem{
+						Message: message,
+						Type:    category.name,
+						Body:    diff,
+					},
+				})
+				s.Failures++
+			}
+		}
+	}
+
+	for _, stats := range results.RecipeStats {
+		s := suite(stats.Name)
+		s.Time = fmt.Sprintf("%.3f", stats.Duration.Seconds())
+		for _, recipeErr := range stats.Errors {
+			s.TestCases = append(s.TestCases, junitTestCase{
+				Name:      filepath.ToSlash(recipeErr.Path),
+				ClassName: stats.Name,
+				Error: &junitProblem{
+					Message: recipeErr.Err.Error(),
+					Type:    fmt.Sprintf("%T", recipeErr.Err),
+					Body:    strings.TrimSpace(recipeErr.Error() + "\n\n" + recipeErr.Stack),
+				},
+			})
+			s.Errors++
+		}
+	}
+
+	testSuites := junitTestSuites{
+		Name: report.Tool,
+		Time: fmt.Sprintf("%.3f", float64(report.DurationMs)/1000),
+	}
+	for _, name := range names {
+		s := suites[name]
+		s.Tests = len(s.TestCases)
+		if s.Time == "" {
+			s.Time = "0.000"
+		}
+		testSuites.Tests += s.Tests
+		testSuites.Failures += s.Failures
+		testSuites.Errors += s.Errors
+		testSuites.Suites = append(testSuites.Suites, *s)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(testSuites)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// junitFailureMessages returns the failure message of each recipe that changed the content of a result
+// The message is the first hunk of the diff the recipe changed, or the first hunk if that is not known.
+// A result whose content is unchanged, as when a file is only moved, has no messages.
+func junitFailureMessages(result Result) map[string]string {
+	var before, after string
+	if result.Before != nil {
+		before = result.Before.Content
+	}
+	if result.After != nil {
+		after = result.After.Content
+	}
+	hunks := Hunks(DiffText(before, after), patchContextLines)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	messages := map[string]string{}
+	if result.Before != nil && result.After != nil {
+		for i, recipes := range result.hunkRecipes(hunks) {
+			for _, recipe := range recipes {
+				if _, ok := messages[recipe]; !ok {
+					messages[recipe] = hunks[i].String()
+				}
+			}
+		}
+	}
+	for _, recipe := range result.RecipesThatMadeChanges {
+		if _, ok := messages[recipe]; !ok {
+			messages[recipe] = hunks[0].String()
+		}
+	}
+	return messages
+}
+
+// resultPath returns the path a result is reported under
+func resultPath(result Result) string {
+	if result.Before == nil {
+		return filepath.ToSlash(result.After.Path)
+	}
+	if result.After != nil && result.After.Path != result.Before.Path {
+		return filepath.ToSlash(result.Before.Path) + " -> " + filepath.ToSlash(result.After.Path)
+	}
+	return filepath.ToSlash(result.Before.Path)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJUnitFailureMessages(t *testing.T) {
+	lines := func(first, last string) string {
+		return first + "\n" + strings.Repeat("same\n", 10) + last + "\n"
+	}
+	before := &SourceFile{Path: "a.txt", Content: lines("a", "f")}
+	middle := &SourceFile{Path: "a.txt", Content: lines("A", "f")}
+	after := &SourceFile{Path: "a.txt", Content: lines("A", "F")}
+
+	result := Result{
+		Before:                 before,
+		After:                  after,
+		RecipesThatMadeChanges: []string{"A", "F"},
+		edits:                  []recipeEdit{{recipe: "A", before: before, after: middle}, {recipe: "F", before: middle, after: after}},
+	}
+	want := map[string]string{
+		"A": "@@ -1,4 +1,4 @@\n-a\n+A\n same\n same\n same\n",
+		"F": "@@ -9,4 +9,4 @@\n same\n same\n same\n-f\n+F\n",
+	}
+	if got := junitFailureMessages(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("junitFailureMessages() = %q, want %q", got, want)
+	}
+
+	moved := Result{Before: before, After: &SourceFile{Path: "b.txt", Content: before.Content}, RecipesThatMadeChanges: []string{"A"}}
+	if got := junitFailureMessages(moved); got != nil {
+		t.Errorf("junitFailureMessages() of a moved file = %q, want none", got)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// parseLogLevel parses a log level such as info or debug
+func parseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", level)
+	}
+}
+
+// NewLogger creates the logger of a run from the log level and format of the configuration
+// Logs are written to w, which is stderr for the CLI so stdout only carries the output of a command.
+func NewLogger(w io.Writer, config *Config) (*slog.Logger, error) {
+	level, err := parseLogLevel(config.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(config.LogFormat) {
+	case LogFormatText, "":
+		return slog.New(newConsoleHandler(w, level)), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected %s or %s", config.LogFormat, LogFormatText, LogFormatJSON)
+	}
+}
+
+// defaultLogger is the logger used when the configuration does not describe a valid one
+func defaultLogger() *slog.Logger {
+	return slog.New(newConsoleHandler(os.Stderr, slog.LevelInfo))
+}
+
+// consoleHandler is the slog handler of the text log format
+// Records are written as single lines in the format the tool has always used, followed by their
+// attributes as key=value pairs. The level is only shown when it is not INFO. Attribute values
+// that span several lines, such as stack traces, are written indented below the record.
+type consoleHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	prefix string
+	attrs  string
+}
+
+// newConsoleHandler creates a text handler writing records at or above level to w
+func newConsoleHandler(w io.Writer, level slog.Leveler) *consoleHandler {
+	return &consoleHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+// Enabled implements slog.Handler
+func (h *consoleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle implements slog.Handler
+func (h *consoleHandler) Handle(ctx context.Context, record slog.Record) error {
+	var line, blocks bytes.Buffer
+	line.WriteString("[REWRITE] ")
+	if !record.Time.IsZero() {
+		line.WriteString(record.Time.Format("2006/01/02 15:04:05 "))
+	}
+	if record.Level != slog.LevelInfo {
+		line.WriteString(record.Level.String())
+		line.WriteByte(' ')
+	}
+	line.WriteString(record.Message)
+	line.WriteString(h.attrs)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		appendConsoleAttr(&line, &blocks, h.prefix, attr)
+		return true
+	})
+	line.WriteByte('\n')
+	line.Write(blocks.Bytes())
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(line.Bytes())
+	return err
+}
+
+// WithAttrs implements slog.Handler
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var line, blocks bytes.Buffer
+	for _, attr := range attrs {
+		appendConsoleAttr(&line, &blocks, h.prefix, attr)
+	}
+	handler := *h
+	handler.attrs += line.String()
+	return &handler
+}
+
+// WithGroup implements slog.Handler
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handler := *h
+	handler.prefix += name + "."
+	return &handler
+}
+
+// appendConsoleAttr writes an attribute as key=value to line, or to blocks if its value spans several lines
+func appendConsoleAttr(line, blocks *bytes.Buffer, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			appendConsoleAttr(line, blocks, prefix, member)
+		}
+		return
+	}
+
+	value := attr.Value.String()
+	if list, ok := attr.Value.Any().([]string); ok {
+		value = strings.Join(list, ",")
+	}
+	if strings.Contains(strings.TrimRight(value, "\n"), "\n") {
+		for _, text := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+			blocks.WriteString("    ")
+			blocks.WriteString(text)
+			blocks.WriteByte('\n')
+		}
+		return
+	}
+
+	if value == "" || strings.ContainsAny(value, " =\"\t") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(line, " %s%s=%s", prefix, attr.Key, value)
+}
+
+// newRunLogger creates the logger of a run, falling back to the default logger for an invalid configuration
+// The configuration is validated when it is loaded, so the fallback only applies to runners created in code.
+func newRunLogger(config *Config) *slog.Logger {
+	if config == nil {
+		return defaultLogger()
+	}
+	logger, err := NewLogger(os.Stderr, config)
+	if err != nil {
+		return defaultLogger()
+	}
+	return logger
+}
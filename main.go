@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -13,13 +14,26 @@ var (
 	config *Config
 
 	// Command line flags
-	configFile    string
-	activeRecipes []string
-	activeStyles  []string
-	baseDir       string
-	dryRun        bool
-	skip          bool
-	verbose       bool
+	configFile      string
+	activeRecipes   []string
+	activeStyles    []string
+	baseDir         string
+	dryRun          bool
+	skip            bool
+	verbose         bool
+	quiet           bool
+	conflictPolicy  string
+	forceUndo       bool
+	forceInit       bool
+	discoverOptions DiscoverOptions
+	printDiff       bool
+	failOnChanges   bool
+	reports         []string
+	reportOutDir    string
+	exportTables    bool
+	profile         bool
+	cpuProfile      string
+	heapProfile     string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -38,10 +52,30 @@ Examples:
   rewrite-go run                                    # Run with default configuration
   rewrite-go run --config custom-rewrite.yml       # Use custom config file
   rewrite-go run --active-recipes Recipe1,Recipe2  # Specify recipes
+  rewrite-go run --active-recipes tag:hygiene      # Activate every recipe with a tag
   rewrite-go dry-run                               # Preview changes without applying
-  rewrite-go discover                              # List available recipes`,
+  rewrite-go search                                # List the matches of search recipes
+  rewrite-go discover                              # List available recipes
+  rewrite-go init                                  # Write a starter rewrite.yml
+  rewrite-go validate                              # Check rewrite.yml for mistakes
+  rewrite-go schema                                # Print the JSON Schema of rewrite.yml
+
+Exit codes:
+  0    success
+  1    unexpected failure, e.g. an I/O error or --timeout expired
+  2    invalid configuration, flags or rewrite.yml
+  3    a recipe produced an error
+  4    a dry run would make changes and --fail-on-dry-run-results is set
+  130  the run was interrupted, changes in flight were rolled back`,
+	// Errors are logged by main in the configured log format
+	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return initConfig()
+		if err := initConfig(); err != nil {
+			return configError(err)
+		}
+		// The flags are valid, so later errors are not about usage
+		cmd.SilenceUsage = true
+		return nil
 	},
 }
 
@@ -59,7 +93,9 @@ This command will:
 
 Use --dry-run to preview changes without applying them.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runRewrite(false)
+		return withProfiling(cpuProfile, heapProfile, func() error {
+			return runRewrite(false)
+		})
 	},
 }
 
@@ -74,7 +110,29 @@ This is useful for:
 - Understanding what recipes would do to your code
 - Testing recipe configurations`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runRewrite(true)
+		return withProfiling(cpuProfile, heapProfile, func() error {
+			return runRewrite(true)
+		})
+	},
+}
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "List the matches of search recipes",
+	Long: `Run the active recipes and list the regions of files they matched, without changing any file.
+
+Search recipes such as org.openrewrite.text.Find mark matches instead of editing code.
+Every match is printed as file:line:col with the lines around it. Use --report json=...
+or --report sarif=... to get the matches in machine-readable form, and --export-datatables
+to export the data tables the recipes produce.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withProfiling(cpuProfile, heapProfile, func() error {
+			ctx, stop := newRunContext(config.Timeout)
+			defer stop()
+			return NewRunner(NewRewriter(config, baseDir)).Search(ctx)
+		})
 	},
 }
 
@@ -84,36 +142,193 @@ var discoverCmd = &cobra.Command{
 	Short: "List available recipes",
 	Long: `Discover and list all available recipes that can be applied.
 
-This command will scan the classpath and configuration files to find
-all available transformation recipes and display them with their descriptions.`,
+Lists the built-in recipes and the recipes declared in the configuration, grouped by
+category, followed by the available and active styles and the active recipes.
+
+Use --recipe to describe a single recipe, looked up ignoring case, with its options,
+their types and defaults. --detail describes every listed recipe the same way, and
+--recursion N expands the recipe lists of declarative recipes N levels deep. --tag and
+--category list only the recipes with a tag or in a category or package.
+
+--search ranks the recipes whose name, display name, tags, option names or description
+match every word of a query, also matching names fuzzily, and shows the matching parts.
+With --json the results are written as JSON, e.g. for editor integrations.
+
+Examples:
+  rewrite-go discover --detail
+  rewrite-go discover --recipe org.openrewrite.text.findandreplace
+  rewrite-go discover --recipe com.example.Migrate --recursion 2
+  rewrite-go discover --tag security --category org.openrewrite.text
+  rewrite-go discover --search "replace regex" --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return NewRunner(NewRewriter(config, baseDir)).Discover(discoverOptions)
+	},
+}
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo [run-id]",
+	Short: "Restore the project to the state before a run",
+	Long: `Undo a previous run by restoring every file it generated, deleted, moved or changed.
+
+Each run records the previous contents, modes and paths of the files it touches
+under .rewrite/runs/<run-id>. Without a run id, the most recent run that has not
+been undone yet is restored. Use 'rewrite-go history' to list previous runs.
+
+Files changed after the run are not overwritten unless --force is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runID := ""
+		if len(args) > 0 {
+			runID = args[0]
+		}
+		return NewRunner(NewRewriter(config, baseDir)).Undo(runID, forceUndo)
+	},
+}
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List previous runs",
+	Long:  `List the runs recorded under .rewrite/runs, most recent first, together with their undo status.`,
+	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return discoverRecipes()
+		return NewRunner(NewRewriter(config, baseDir)).History()
+	},
+}
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration for mistakes",
+	Long: `Check rewrite.yml and the active recipes without processing any files.
+
+Reports, with the file, line and column where known:
+  - YAML that does not have the structure of a configuration, and unknown keys
+  - active recipes and recipe list entries that are not available, with suggestions
+  - built-in recipes missing required options, or given unknown options or values of the wrong type
+  - recipes declared more than once, and --active-recipes selectors that select nothing
+
+The same checks run before run, dry-run and search, which fail on errors when
+failOnInvalidActiveRecipes is set and only warn otherwise. validate always fails on errors.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return NewRunner(NewRewriter(config, baseDir)).Validate()
+	},
+}
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of rewrite.yml",
+	Long: `Print a JSON Schema of rewrite.yml to stdout, including the options of every built-in
+recipe as typed properties of its recipe list entries.
+
+Editors with a YAML language server use it to complete and check rewrite.yml, e.g.
+  rewrite-go schema > rewrite.schema.json
+and a first line in rewrite.yml of
+  # yaml-language-server: $schema=rewrite.schema.json
+
+validate and the checks before runs use the same schema.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return WriteConfigurationSchema(os.Stdout)
+	},
+}
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a starter rewrite.yml for the project",
+	Long: `Inspect the project and write a commented rewrite.yml to start from.
+
+The project is inspected for a pom.xml, the languages of its source files, a checkstyle
+configuration and the indentation of an .editorconfig. The configuration declares a
+starter recipe that only reports findings, and a style with the detected indentation.
+
+The file is written to the path of --config, or rewrite.yml in the base directory. An
+existing file is only overwritten with --force.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := newRunContext(config.Timeout)
+		defer stop()
+		return NewRunner(NewRewriter(config, baseDir)).Init(ctx, InitOptions{Path: configFile, Force: forceInit})
 	},
 }
 
 func init() {
+	// Invalid flags are configuration errors
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return configError(err)
+	})
+
 	// Add subcommands
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(dryRunCmd)
+	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(discoverCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(initCmd)
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default is rewrite.yml)")
-	rootCmd.PersistentFlags().StringSliceVar(&activeRecipes, "active-recipes", []string{}, "comma-separated list of recipes to activate")
+	rootCmd.PersistentFlags().StringSliceVar(&activeRecipes, "active-recipes", []string{}, "comma-separated list of recipes to activate, as names, globs such as org.openrewrite.text.* or tag:<tag>")
 	rootCmd.PersistentFlags().StringSliceVar(&activeStyles, "active-styles", []string{}, "comma-separated list of styles to activate")
 	rootCmd.PersistentFlags().StringVar(&baseDir, "base-dir", "", "base directory to process (default is current directory)")
 	rootCmd.PersistentFlags().BoolVar(&skip, "skip", false, "skip execution")
+	rootCmd.PersistentFlags().StringSliceVar(&reports, "report", []string{}, "structured report to write as format=path, e.g. json=report.json (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&reportOutDir, "report-output-directory", "", "directory for reports such as rewrite.patch (default is target/rewrite)")
+	rootCmd.PersistentFlags().BoolVar(&exportTables, "export-datatables", false, "export the data tables recipes produce as CSV under the report output directory")
+	rootCmd.PersistentFlags().Int("parallelism", 0, "number of files to process concurrently (default is GOMAXPROCS, 1 with --profile)")
+	rootCmd.PersistentFlags().BoolVar(&profile, "profile", false, "log the slowest recipes and parsers with their allocations at the end of the run")
+	rootCmd.PersistentFlags().Int("profile-top", defaultProfileTop, "number of recipes and parsers --profile shows")
+	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpu-profile", "", "write a pprof CPU profile of the run to this file")
+	rootCmd.PersistentFlags().StringVar(&heapProfile, "heap-profile", "", "write a pprof heap profile at the end of the run to this file")
+	rootCmd.PersistentFlags().String("recipe-error-policy", RecipeErrorPolicyContinueAndReport, "what to do when a recipe fails on a file: fail-fast, continue-and-report or continue-and-apply-successful")
+	rootCmd.PersistentFlags().Bool("fail-on-invalid-active-recipes", false, "fail instead of warning when validating the configuration before a run finds errors")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "stop the run after this duration as if interrupted, e.g. 10m (default is no timeout)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only log warnings and errors")
+	rootCmd.PersistentFlags().String("progress", ProgressAuto, "how to show the progress of long runs: auto, bar, log or none")
+	rootCmd.PersistentFlags().String("log-format", LogFormatText, "format of the logs written to stderr: text or json")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
 
 	// Command-specific flags
 	runCmd.Flags().BoolVar(&dryRun, "dry-run", false, "preview changes without applying them")
+	runCmd.Flags().StringVar(&conflictPolicy, "conflict-policy", ConflictPolicyFail, "what to do when a file changed on disk during the run: fail or skip")
+	runCmd.Flags().BoolVar(&printDiff, "diff", false, "print the unified diff of a dry run to stdout")
+	dryRunCmd.Flags().BoolVar(&printDiff, "diff", false, "print the unified diff to stdout")
+	dryRunCmd.Flags().BoolVar(&failOnChanges, "fail-on-dry-run-results", false, "exit with code 4 if recipes would make changes")
+	discoverCmd.Flags().StringVar(&discoverOptions.Recipe, "recipe", "", "describe a single recipe, matched ignoring case")
+	discoverCmd.Flags().BoolVar(&discoverOptions.Detail, "detail", false, "show the display name, description and options of every recipe")
+	discoverCmd.Flags().StringVar(&discoverOptions.Tag, "tag", "", "list only the recipes with this tag")
+	discoverCmd.Flags().StringVar(&discoverOptions.Category, "category", "", "list only the recipes of this category or package")
+	discoverCmd.Flags().StringVar(&discoverOptions.Search, "search", "", "list the recipes matching a query, best matches first")
+	discoverCmd.Flags().BoolVar(&discoverOptions.JSON, "json", false, "write the results of --search as JSON")
+	discoverCmd.Flags().IntVar(&discoverOptions.Recursion, "recursion", 0, "number of levels of recipe lists to expand")
+	initCmd.Flags().BoolVar(&forceInit, "force", false, "overwrite an existing configuration file")
+	undoCmd.Flags().BoolVar(&forceUndo, "force", false, "undo even if files changed since the run")
 
 	// Bind flags to viper
 	viper.BindPFlag("config-location", rootCmd.PersistentFlags().Lookup("config"))
 	viper.BindPFlag("active-recipes", rootCmd.PersistentFlags().Lookup("active-recipes"))
 	viper.BindPFlag("active-styles", rootCmd.PersistentFlags().Lookup("active-styles"))
 	viper.BindPFlag("skip", rootCmd.PersistentFlags().Lookup("skip"))
+	viper.BindPFlag("report-output-directory", rootCmd.PersistentFlags().Lookup("report-output-directory"))
+	viper.BindPFlag("export-datatables", rootCmd.PersistentFlags().Lookup("export-datatables"))
+	viper.BindPFlag("parallelism", rootCmd.PersistentFlags().Lookup("parallelism"))
+	viper.BindPFlag("profile-top", rootCmd.PersistentFlags().Lookup("profile-top"))
+	viper.BindPFlag("recipe-error-policy", rootCmd.PersistentFlags().Lookup("recipe-error-policy"))
+	viper.BindPFlag("progress", rootCmd.PersistentFlags().Lookup("progress"))
+	viper.BindPFlag("log-format", rootCmd.PersistentFlags().Lookup("log-format"))
+	viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
+	viper.BindPFlag("fail-on-invalid-active-recipes", rootCmd.PersistentFlags().Lookup("fail-on-invalid-active-recipes"))
 	viper.BindPFlag("dry-run", runCmd.Flags().Lookup("dry-run"))
+	viper.BindPFlag("conflict-policy", runCmd.Flags().Lookup("conflict-policy"))
 }
 
 // initConfig reads in config file and ENV variables if set
@@ -163,6 +378,13 @@ func initConfig() error {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// The bound --config flag unmarshals to an empty location when it is not set
+	if configFile != "" {
+		config.ConfigLocation = configFile
+	} else if config.ConfigLocation == "" {
+		config.ConfigLocation = "rewrite.yml"
+	}
+
 	// Override with command line flags
 	if len(activeRecipes) > 0 {
 		config.ActiveRecipes = activeRecipes
@@ -170,14 +392,36 @@ func initConfig() error {
 	if len(activeStyles) > 0 {
 		config.ActiveStyles = activeStyles
 	}
+	if len(reports) > 0 {
+		config.Reports = reports
+	}
 	if skip {
 		config.Skip = true
 	}
+	if failOnChanges {
+		config.FailOnDryRunResults = true
+	}
+	if profile {
+		config.Profile = true
+	}
 
-	// Set log level based on verbose flag
+	// Set log level based on verbose and quiet flags
 	if verbose {
 		config.LogLevel = "debug"
 	}
+	if quiet {
+		config.LogLevel = "warn"
+	}
+
+	// Validate the logging configuration before anything is logged
+	logger, err := NewLogger(io.Discard, config)
+	if err != nil {
+		return err
+	}
+	_, err = NewProgress(logger, config)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -194,54 +438,31 @@ func runRewrite(isDryRun bool) error {
 
 	// Create runner
 	runner := NewRunner(rewriter)
+	if printDiff {
+		runner.DiffOutput = os.Stdout
+	}
+
+	// Stop on SIGINT or once the timeout expires
+	ctx, stop := newRunContext(config.Timeout)
+	defer stop()
 
 	// Execute
 	if isDryRun || dryRun {
-		return runner.DryRun()
+		return runner.DryRun(ctx)
 	} else {
-		return runner.Execute()
-	}
-}
-
-// discoverRecipes lists available recipes
-func discoverRecipes() error {
-	fmt.Println("Available recipes:")
-	fmt.Println("(This is a placeholder - recipe discovery would be implemented here)")
-
-	// Create rewriter to load environment
-	rewriter := NewRewriter(config, baseDir)
-	err := rewriter.LoadEnvironment()
-	if err != nil {
-		return fmt.Errorf("failed to load environment: %w", err)
+		return runner.Execute(ctx)
 	}
-
-	if rewriter.Environment != nil {
-		fmt.Printf("\nLoaded %d recipes from configuration:\n", len(rewriter.Environment.ActiveRecipes))
-		for _, recipe := range rewriter.Environment.ActiveRecipes {
-			fmt.Printf("  - %s", recipe.Name)
-			if recipe.DisplayName != "" {
-				fmt.Printf(" (%s)", recipe.DisplayName)
-			}
-			if recipe.Description != "" {
-				fmt.Printf(": %s", recipe.Description)
-			}
-			fmt.Println()
-		}
-
-		fmt.Printf("\nLoaded %d styles from configuration:\n", len(rewriter.Environment.ActiveStyles))
-		for _, style := range rewriter.Environment.ActiveStyles {
-			fmt.Printf("  - %s\n", style.Name)
-		}
-	}
-
-	return nil
 }
 
 // main is the entry point
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		logger := defaultLogger()
+		if config != nil {
+			logger = newRunLogger(config)
+		}
+		logger.Error("Command failed", "error", err)
+		os.Exit(exitCode(err))
 	}
 }
 
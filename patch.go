@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// patchContextLines is the number of unchanged lines shown around each change
+const patchContextLines = 3
+
+// gitFileMode returns the mode git records for a regular file with the given permissions
+func gitFileMode(mode os.FileMode) string {
+	if mode&0111 != 0 {
+		return "100755"
+	}
+	return "100644"
+}
+
+// UnifiedDiff renders a result as a git-style unified diff that git apply accepts
+func UnifiedDiff(result Result) string {
+	var sb strings.Builder
+
+	var before, after string
+	var oldPath, newPath string
+	if result.Before != nil {
+		before = result.Before.Content
+		oldPath = filepath.ToSlash(result.Before.Path)
+	}
+	if result.After != nil {
+		after = result.After.Content
+		newPath = filepath.ToSlash(result.After.Path)
+	}
+
+	switch {
+	case result.Before == nil:
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", newPath, newPath)
+		fmt.Fprintf(&sb, "new file mode %s\n", gitFileMode(result.After.Mode))
+	case result.After == nil:
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", oldPath, oldPath)
+		fmt.Fprintf(&sb, "deleted file mode %s\n", gitFileMode(result.Before.Mode))
+	default:
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", oldPath, newPath)
+		if oldMode, newMode := gitFileMode(result.Before.Mode), gitFileMode(result.After.Mode); oldMode != newMode {
+			fmt.Fprintf(&sb, "old mode %s\n", oldMode)
+			fmt.Fprintf(&sb, "new mode %s\n", newMode)
+		}
+		if oldPath != newPath {
+			fmt.Fprintf(&sb, "similarity index %d%%\n", similarity(before, after))
+			fmt.Fprintf(&sb, "rename from %s\n", oldPath)
+			fmt.Fprintf(&sb, "rename to %s\n", newPath)
+		}
+	}
+
+	hunks := Hunks(DiffText(before, after), patchContextLines)
+	if len(hunks) == 0 {
+		return sb.String()
+	}
+
+	if result.Before == nil {
+		sb.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&sb, "--- a/%s\n", oldPath)
+	}
+	if result.After == nil {
+		sb.WriteString("+++ /dev/null\n")
+	} else {
+		fmt.Fprintf(&sb, "+++ b/%s\n", newPath)
+	}
+
+	for _, hunk := range hunks {
+		sb.WriteString(hunk.String())
+	}
+
+	return sb.String()
+}
+
+// similarity estimates how much of a moved file's content was kept, as a percentage
+func similarity(before, after string) int {
+	total := len(splitLines(before)) + len(splitLines(after))
+	if total == 0 {
+		return 100
+	}
+
+	unchanged := 0
+	for _, line := range DiffText(before, after) {
+		if line.Op == DiffEqual {
+			unchanged++
+		}
+	}
+
+	return 200 * unchanged / total
+}
+
+// WritePatch writes the diffs of all results to w
+// Results are written in the same order the Java plugin uses: generated, deleted, moved and refactored files.
+func WritePatch(w io.Writer, results *ResultsContainer) error {
+	return writePatch(context.Background(), w, results, nil)
+}
+
+// writePatch writes the patch of all results to w, reporting every diff to progress
+// Writing stops with the cause of the cancellation when ctx is cancelled.
+func writePatch(ctx context.Context, w io.Writer, results *ResultsContainer, progress *Progress) error {
+	for _, group := range [][]Result{results.Generated, results.Deleted, results.Moved, results.RefactoredInPlace} {
+		for _, result := range group {
+			if err := interruption(ctx); err != nil {
+				return err
+			}
+			_, err := io.WriteString(w, UnifiedDiff(result))
+			if err != nil {
+				return err
+			}
+			progress.Add(1)
+		}
+	}
+	return nil
+}
+
+// writePatchFile writes rewrite.patch to the report output directory and returns its path
+// This mirrors the patch file generation of AbstractRewriteDryRunMojo
+func (r *Runner) writePatchFile(ctx context.Context, buildRoot string, results *ResultsContainer) (string, error) {
+	outDir := r.Rewriter.Config.GetReportOutputDirectory(buildRoot)
+	err := os.MkdirAll(outDir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("could not create the folder [ %s ]: %w", outDir, err)
+	}
+
+	patchFile := filepath.Join(outDir, "rewrite.patch")
+	file, err := os.Create(patchFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to create patch file: %w", err)
+	}
+	defer file.Close()
+
+	r.Rewriter.Progress.Start("Writing patch", results.ChangeCount())
+	err = writePatch(ctx, file, results, r.Rewriter.Progress)
+	r.Rewriter.Progress.Finish()
+	if err != nil {
+		return "", fmt.Errorf("failed to write patch file: %w", err)
+	}
+
+	return patchFile, file.Close()
+}
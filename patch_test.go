@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiffHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   string
+	}{
+		{
+			name: "modified",
+			result: Result{
+				Before: &SourceFile{Path: "a.txt", Content: "a\nb\n", Mode: 0644},
+				After:  &SourceFile{Path: "a.txt", Content: "a\nc\n", Mode: 0644},
+			},
+			want: "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+		{
+			name:   "new",
+			result: Result{After: &SourceFile{Path: "new.txt", Content: "n\n", Mode: 0644}},
+			want:   "diff --git a/new.txt b/new.txt\nnew file mode 100644\n--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+n\n",
+		},
+		{
+			name:   "deleted",
+			result: Result{Before: &SourceFile{Path: "old.txt", Content: "o", Mode: 0644}},
+			want:   "diff --git a/old.txt b/old.txt\ndeleted file mode 100644\n--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-o\n\\ No newline at end of file\n",
+		},
+		{
+			name: "renamed",
+			result: Result{
+				Before: &SourceFile{Path: "from.txt", Content: "x\n", Mode: 0644},
+				After:  &SourceFile{Path: "to/to.txt", Content: "x\n", Mode: 0644},
+			},
+			want: "diff --git a/from.txt b/to/to.txt\nsimilarity index 100%\nrename from from.txt\nrename to to/to.txt\n",
+		},
+		{
+			name: "mode changed",
+			result: Result{
+				Before: &SourceFile{Path: "run.sh", Content: "echo\n", Mode: 0644},
+				After:  &SourceFile{Path: "run.sh", Content: "echo\n", Mode: 0755},
+			},
+			want: "diff --git a/run.sh b/run.sh\nold mode 100644\nnew mode 100755\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff(tt.result); got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// gitApplyResults are changes of every kind UnifiedDiff renders, with the tree before them
+var gitApplyResults = []Result{
+	{
+		Before: &SourceFile{Path: "modified.txt", Content: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", Mode: 0644},
+		After:  &SourceFile{Path: "modified.txt", Content: "1\ntwo\n3\n4\n5\n6\n7\n8\nnine\n10\n", Mode: 0644},
+	},
+	{
+		Before: &SourceFile{Path: "no-newline.txt", Content: "a\nb", Mode: 0644},
+		After:  &SourceFile{Path: "no-newline.txt", Content: "a\nc", Mode: 0644},
+	},
+	{
+		Before: &SourceFile{Path: "gains-newline.txt", Content: "a", Mode: 0644},
+		After:  &SourceFile{Path: "gains-newline.txt", Content: "a\n", Mode: 0644},
+	},
+	{After: &SourceFile{Path: "dir/new.txt", Content: "new\n", Mode: 0644}},
+	{After: &SourceFile{Path: "empty.txt", Content: "", Mode: 0644}},
+	{Before: &SourceFile{Path: "deleted.txt", Content: "gone\n", Mode: 0644}},
+	{
+		Before: &SourceFile{Path: "renamed.txt", Content: "same\n", Mode: 0644},
+		After:  &SourceFile{Path: "moved/renamed.txt", Content: "same\n", Mode: 0644},
+	},
+	{
+		Before: &SourceFile{Path: "renamed-and-edited.txt", Content: "a\nb\nc\nd\n", Mode: 0644},
+		After:  &SourceFile{Path: "moved/edited.txt", Content: "a\nb\nc\nD\n", Mode: 0644},
+	},
+	{
+		Before: &SourceFile{Path: "run.sh", Content: "echo\n", Mode: 0644},
+		After:  &SourceFile{Path: "run.sh", Content: "echo\n", Mode: 0755},
+	},
+	{
+		Before: &SourceFile{Path: "tool.sh", Content: "old\n", Mode: 0755},
+		After:  &SourceFile{Path: "tool.sh", Content: "new\n", Mode: 0644},
+	},
+}
+
+func TestUnifiedDiffAppliesWithGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	var patch strings.Builder
+	for _, result := range gitApplyResults {
+		if result.Before != nil {
+			path := filepath.Join(dir, result.Before.Path)
+			if err := os.WriteFile(path, []byte(result.Before.Content), result.Before.Mode); err != nil {
+				t.Fatal(err)
+			}
+		}
+		patch.WriteString(UnifiedDiff(result))
+	}
+	patchFile := filepath.Join(t.TempDir(), "rewrite.patch")
+	if err := os.WriteFile(patchFile, []byte(patch.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{{"apply", "--check", patchFile}, {"apply", patchFile}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v\n%s\npatch:\n%s", strings.Join(args, " "), err, output, patch.String())
+		}
+	}
+
+	for _, result := range gitApplyResults {
+		if result.Before != nil && (result.After == nil || result.After.Path != result.Before.Path) {
+			if _, err := os.Stat(filepath.Join(dir, result.Before.Path)); !os.IsNotExist(err) {
+				t.Errorf("%s still exists", result.Before.Path)
+			}
+		}
+		if result.After == nil {
+			continue
+		}
+		path := filepath.Join(dir, result.After.Path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", result.After.Path, err)
+			continue
+		}
+		if string(content) != result.After.Content {
+			t.Errorf("%s = %q, want %q", result.After.Path, content, result.After.Content)
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0111 != result.After.Mode&0111 {
+			t.Errorf("%s has mode %v, want %v", result.After.Path, info.Mode().Perm(), result.After.Mode)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultProfileTop is the number of rows the profile tables show by default
+const defaultProfileTop = 10
+
+// ParserStats holds statistics of reading and parsing source files of one kind
+type ParserStats struct {
+	Name           string
+	Duration       time.Duration
+	Files          int
+	Bytes          int64
+	AllocatedBytes uint64
+}
+
+// parserExtensions maps file extensions to the parser that reads them
+// Files with other extensions are read as plain text.
+var parserExtensions = map[string]string{
+	".java": "java", ".kt": "kotlin", ".groovy": "groovy", ".gradle": "groovy", ".scala": "scala",
+	".js": "javascript", ".jsx": "javascript", ".ts": "typescript", ".tsx": "typescript",
+	".go": "go", ".py": "python",
+	".xml": "xml", ".json": "json", ".yaml": "yaml", ".yml": "yaml",
+	".properties": "properties", ".toml": "toml", ".hcl": "hcl", ".tf": "hcl", ".proto": "protobuf",
+}
+
+// parserName returns the name of the parser that reads the file at path
+func parserName(path string) string {
+	if filepath.Base(path) == "pom.xml" {
+		return "maven"
+	}
+	if parser, ok := parserExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return parser
+	}
+	return "text"
+}
+
+// allocCounter reads the number of bytes the program allocated so far
+// It uses runtime/metrics, which does not stop the world like runtime.ReadMemStats.
+// The counter covers all goroutines, which is why profiled runs process one file at a time.
+// Small allocations are counted in batches, so figures for short steps are approximate.
+type allocCounter struct {
+	samples []metrics.Sample
+}
+
+// newAllocCounter creates an allocation counter, or nil if allocations are not profiled
+func newAllocCounter(enabled bool) *allocCounter {
+	if !enabled {
+		return nil
+	}
+	return &allocCounter{samples: []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}}
+}
+
+// read returns the bytes allocated so far, or 0 for a nil counter
+func (c *allocCounter) read() uint64 {
+	if c == nil {
+		return 0
+	}
+	metrics.Read(c.samples)
+	if c.samples[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return c.samples[0].Value.Uint64()
+}
+
+// formatBytes formats a byte count in a human-readable format
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// logProfile logs the slowest recipes and parsers of a run
+func (r *Runner) logProfile(results *ResultsContainer) {
+	top := r.Rewriter.Config.ProfileTop
+	if top <= 0 {
+		top = defaultProfileTop
+	}
+
+	recipes := append([]*RecipeStats(nil), results.RecipeStats...)
+	sort.SliceStable(recipes, func(i, j int) bool {
+		return recipes[i].Duration > recipes[j].Duration
+	})
+	r.Logger.Info("Top recipes by execution time", "count", min(top, len(recipes)))
+	for _, stats := range recipes[:min(top, len(recipes))] {
+		r.Logger.Info("Recipe profile", "recipe", stats.Name, "time", stats.Duration.Round(time.Microsecond),
+			"visited", stats.FilesVisited, "changed", stats.FilesChanged, "allocated", formatBytes(stats.AllocatedBytes))
+	}
+
+	parsers := append([]*ParserStats(nil), results.ParserStats...)
+	sort.SliceStable(parsers, func(i, j int) bool {
+		return parsers[i].Duration > parsers[j].Duration
+	})
+	r.Logger.Info("Top parsers by execution time", "count", min(top, len(parsers)))
+	for _, stats := range parsers[:min(top, len(parsers))] {
+		r.Logger.Info("Parser profile", "parser", stats.Name, "time", stats.Duration.Round(time.Microsecond),
+			"files", stats.Files, "read", formatBytes(uint64(stats.Bytes)), "allocated", formatBytes(stats.AllocatedBytes))
+	}
+}
+
+// withProfiling runs fn while writing a CPU profile to cpuProfile and a heap profile to heapProfile
+// Either path may be empty to skip that profile.
+func withProfiling(cpuProfile, heapProfile string, fn func() error) error {
+	if cpuProfile != "" {
+		file, err := os.Create(cpuProfile)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		defer file.Close()
+
+		err = pprof.StartCPUProfile(file)
+		if err != nil {
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	err := fn()
+	if heapProfile == "" {
+		return err
+	}
+	return errors.Join(err, writeHeapProfile(heapProfile))
+}
+
+// writeHeapProfile writes a heap profile of the live objects to path
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile: %w", err)
+	}
+	defer file.Close()
+
+	// Collect garbage first so the profile shows live objects at the end of the run
+	runtime.GC()
+	err = pprof.WriteHeapProfile(file)
+	if err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	return file.Close()
+}
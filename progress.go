@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress modes
+const (
+	ProgressAuto = "auto"
+	ProgressBar  = "bar"
+	ProgressLog  = "log"
+	ProgressNone = "none"
+)
+
+const (
+	// progressBarInterval is how often the progress bar is redrawn
+	progressBarInterval = 200 * time.Millisecond
+	// progressLogInterval is how often a progress line is logged when not attached to a terminal
+	progressLogInterval = 10 * time.Second
+	// progressBarWidth is the number of characters of the bar itself
+	progressBarWidth = 30
+)
+
+// Progress reports the progress of the phases of a run: discovery, processing and applying changes
+// Depending on the mode it redraws a progress bar with an ETA on a terminal, or logs a line at a fixed
+// interval. Only one phase is reported at a time. Add may be called concurrently, and every method is a
+// no-op on a nil Progress, so callers need not check whether progress is reported.
+type Progress struct {
+	logger   *slog.Logger
+	bar      io.Writer
+	interval time.Duration
+
+	mu    sync.Mutex
+	phase *progressPhase
+
+	// barMu serializes drawing the bar and writing log records, barDrawn is set while a bar line is shown
+	barMu    sync.Mutex
+	barDrawn bool
+}
+
+// progressPhase is a phase of a run whose progress is being reported
+type progressPhase struct {
+	name    string
+	total   int
+	done    atomic.Int64
+	started time.Time
+	logged  bool
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewProgress creates the progress reporter of a run from the progress mode of the configuration
+// In auto mode a bar is drawn when stderr is a terminal and the logs are text, otherwise progress is
+// logged. It returns nil when progress is disabled, or when the logger would drop the progress lines.
+func NewProgress(logger *slog.Logger, config *Config) (*Progress, error) {
+	mode := config.Progress
+	switch mode {
+	case ProgressAuto, "":
+		mode = ProgressLog
+		if isTerminal(os.Stderr) && !strings.EqualFold(config.LogFormat, LogFormatJSON) {
+			mode = ProgressBar
+		}
+	case ProgressBar, ProgressLog, ProgressNone:
+	default:
+		return nil, fmt.Errorf("unknown progress mode %q, expected %s, %s, %s or %s", config.Progress, ProgressAuto, ProgressBar, ProgressLog, ProgressNone)
+	}
+
+	level, err := parseLogLevel(config.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	if mode == ProgressNone || level > slog.LevelInfo {
+		return nil, nil
+	}
+
+	if mode == ProgressBar {
+		return &Progress{logger: logger, bar: os.Stderr, interval: progressBarInterval}, nil
+	}
+	return &Progress{logger: logger, interval: progressLogInterval}, nil
+}
+
+// isTerminal reports whether a file is a terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Start begins reporting a phase, ending the previous one
+// A total of zero means the amount of work is not known in advance, as when discovering files.
+func (p *Progress) Start(name string, total int) {
+	if p == nil {
+		return
+	}
+	p.Finish()
+
+	phase := &progressPhase{
+		name:    name,
+		total:   total,
+		started: time.Now(),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	p.mu.Lock()
+	p.phase = phase
+	p.mu.Unlock()
+
+	go func() {
+		defer close(phase.stopped)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.report(phase)
+			case <-phase.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Add records that n more units of work of the current phase are done
+func (p *Progress) Add(n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	phase := p.phase
+	p.mu.Unlock()
+	if phase != nil {
+		phase.done.Add(int64(n))
+	}
+}
+
+// Finish ends the current phase
+// If progress of the phase was reported, the bar is removed, or in log mode a final line is logged.
+func (p *Progress) Finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	phase := p.phase
+	p.phase = nil
+	p.mu.Unlock()
+	if phase == nil {
+		return
+	}
+
+	close(phase.stop)
+	<-phase.stopped
+
+	if !phase.logged {
+		return
+	}
+	if p.bar != nil {
+		p.barMu.Lock()
+		p.clearBar()
+		p.barMu.Unlock()
+		return
+	}
+	p.logger.Info(phase.name, "done", phase.done.Load(), "elapsed", time.Since(phase.started).Round(time.Second))
+}
+
+// report draws the bar or logs a progress line for a phase
+func (p *Progress) report(phase *progressPhase) {
+	done := int(phase.done.Load())
+	elapsed := time.Since(phase.started)
+
+	var eta time.Duration
+	if phase.total > 0 && done > 0 && done < phase.total {
+		eta = time.Duration(float64(elapsed) / float64(done) * float64(phase.total-done)).Round(time.Second)
+	}
+
+	phase.logged = true
+	if p.bar == nil {
+		if phase.total == 0 {
+			p.logger.Info(phase.name, "done", done, "elapsed", elapsed.Round(time.Second))
+			return
+		}
+		p.logger.Info(phase.name, "done", done, "total", phase.total, "percent", done*100/phase.total, "eta", eta)
+		return
+	}
+
+	if phase.total == 0 {
+		p.drawBar("%s %d %s", phase.name, done, elapsed.Round(time.Second))
+		return
+	}
+	filled := min(done*progressBarWidth/phase.total, progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	p.drawBar("%s [%s] %3d%% %d/%d ETA %s", phase.name, bar, done*100/phase.total, done, phase.total, eta)
+}
+
+// drawBar replaces the bar line with a new one
+func (p *Progress) drawBar(format string, args ...interface{}) {
+	p.barMu.Lock()
+	defer p.barMu.Unlock()
+	fmt.Fprintf(p.bar, "\r\033[K"+format, args...)
+	p.barDrawn = true
+}
+
+// clearBar removes the bar line if one is shown, the caller holds barMu
+func (p *Progress) clearBar() {
+	if p.barDrawn {
+		fmt.Fprint(p.bar, "\r\033[K")
+		p.barDrawn = false
+	}
+}
+
+// wrapLogger returns a logger that removes the bar before writing a record, so records never run into the
+// bar line. The bar is drawn again below the record at the next redraw. Without a bar logger is returned as is.
+func (p *Progress) wrapLogger(logger *slog.Logger) *slog.Logger {
+	if p == nil || p.bar == nil {
+		return logger
+	}
+	return slog.New(&progressHandler{Handler: logger.Handler(), progress: p})
+}
+
+// progressHandler is a slog handler clearing the progress bar before every record it writes
+type progressHandler struct {
+	slog.Handler
+	progress *Progress
+}
+
+// Handle implements slog.Handler
+func (h *progressHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.progress.barMu.Lock()
+	defer h.progress.barMu.Unlock()
+	h.progress.clearBar()
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h *progressHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &progressHandler{Handler: h.Handler.WithAttrs(attrs), progress: h.progress}
+}
+
+// WithGroup implements slog.Handler
+func (h *progressHandler) WithGroup(name string) slog.Handler {
+	return &progressHandler{Handler: h.Handler.WithGroup(name), progress: h.progress}
+}
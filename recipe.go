@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RecipeListEntry is a reference to a recipe in a recipeList, optionally with options
+// In YAML it is either a plain recipe name or a single-key map from the name to its options:
+//
+//	recipeList:
+//	  - org.openrewrite.java.format.AutoFormat
+//	  - org.openrewrite.text.FindAndReplace:
+//	      find: foo
+//	      replace: bar
+type RecipeListEntry struct {
+	Name    string
+	Options map[string]interface{}
+
+	// position is where the entry is in the configuration, for validation issues
+	position configPosition
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (e *RecipeListEntry) UnmarshalYAML(node *yaml.Node) error {
+	e.position = configPosition{Line: node.Line, Column: node.Column}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		e.Name = node.Value
+		return nil
+	case yaml.MappingNode:
+		if len(node.Content) != 2 {
+			return fmt.Errorf("line %d: a recipe list entry must have exactly one recipe name", node.Line)
+		}
+		e.Name = node.Content[0].Value
+		if node.Content[1].Kind == yaml.ScalarNode && node.Content[1].Tag == "!!null" {
+			return nil
+		}
+		return node.Content[1].Decode(&e.Options)
+	default:
+		return fmt.Errorf("line %d: a recipe list entry must be a recipe name or a map of a recipe name to its options", node.Line)
+	}
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (e RecipeListEntry) MarshalYAML() (interface{}, error) {
+	if len(e.Options) == 0 {
+		return e.Name, nil
+	}
+	return map[string]interface{}{e.Name: e.Options}, nil
+}
+
+// ExecutionContext carries the state shared by all recipes during a run
+// This mirrors the ExecutionContext from the Java version
+type ExecutionContext struct {
+	// Context is cancelled when the run is interrupted, long running recipes should check it
+	Context    context.Context
+	Config     *Config
+	DataTables *DataTableStore
+	// Styles are the style configurations of the active styles, recipes that format code must follow them
+	Styles *StyleSet
+}
+
+// NewExecutionContext creates an execution context for a run
+// Without styles, recipes format code with the defaults of DefaultStyleSet.
+func NewExecutionContext(ctx context.Context, config *Config, styles *StyleSet) *ExecutionContext {
+	if styles == nil {
+		styles = DefaultStyleSet()
+	}
+	return &ExecutionContext{
+		Context:    ctx,
+		Config:     config,
+		DataTables: NewDataTableStore(),
+		Styles:     styles,
+	}
+}
+
+// RecipeVisitor applies a recipe to source files
+// Visit returns the source file unchanged if the recipe does not apply to it. Files are processed
+// concurrently, so Visit may be called for different source files at the same time.
+type RecipeVisitor interface {
+	Visit(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error)
+}
+
+// RecipeVisitorFunc adapts a function to the RecipeVisitor interface
+type RecipeVisitorFunc func(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error)
+
+// Visit calls f(ctx, sourceFile)
+func (f RecipeVisitorFunc) Visit(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error) {
+	return f(ctx, sourceFile)
+}
+
+// RecipeOption describes an option of a recipe
+type RecipeOption struct {
+	Name        string
+	DisplayName string
+	Description string
+	// Type is the option type: String, Boolean or Integer
+	Type     string
+	Required bool
+	Default  interface{}
+	Example  string
+}
+
+// RecipeDescriptor describes a built-in recipe and creates its visitor
+type RecipeDescriptor struct {
+	Name        string
+	DisplayName string
+	Description string
+	Tags        []string
+	Options     []RecipeOption
+	DataTables  []DataTableDescriptor
+
+	// EstimatedEffortPerOccurrence is the time a developer would spend on a change the recipe makes
+	// If it is zero, defaultEffortPerOccurrence is used.
+	EstimatedEffortPerOccurrence time.Duration
+
+	// New creates the visitor of the recipe configured with the given options
+	New func(options RecipeOptions) (RecipeVisitor, error)
+}
+
+// recipeRegistry holds all built-in recipes by name
+var recipeRegistry = map[string]*RecipeDescriptor{}
+
+// RegisterRecipe makes a built-in recipe available to configurations
+func RegisterRecipe(descriptor *RecipeDescriptor) {
+	if _, exists := recipeRegistry[descriptor.Name]; exists {
+		panic("recipe registered twice: " + descriptor.Name)
+	}
+	recipeRegistry[descriptor.Name] = descriptor
+}
+
+// LookupRecipe returns the built-in recipe with the given name, or nil
+func LookupRecipe(name string) *RecipeDescriptor {
+	return recipeRegistry[name]
+}
+
+// RegisteredRecipes returns all built-in recipes ordered by name
+func RegisteredRecipes() []*RecipeDescriptor {
+	var descriptors []*RecipeDescriptor
+	for _, descriptor := range recipeRegistry {
+		descriptors = append(descriptors, descriptor)
+	}
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].Name < descriptors[j].Name
+	})
+	return descriptors
+}
+
+// RecipeOptions are the option values a recipe is configured with
+type RecipeOptions map[string]interface{}
+
+// String returns the string option with the given name, or def if it is not set
+func (o RecipeOptions) String(name, def string) string {
+	value, ok := o[name]
+	if !ok || value == nil {
+		return def
+	}
+	return fmt.Sprint(value)
+}
+
+// Bool returns the boolean option with the given name, or def if it is not set
+func (o RecipeOptions) Bool(name string, def bool) (bool, error) {
+	value, ok := o[name]
+	if !ok || value == nil {
+		return def, nil
+	}
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return def, fmt.Errorf("option %s must be a boolean, got %q", name, v)
+		}
+		return b, nil
+	default:
+		return def, fmt.Errorf("option %s must be a boolean, got %v", name, value)
+	}
+}
+
+// Int returns the integer option with the given name, or def if it is not set
+func (o RecipeOptions) Int(name string, def int) (int, error) {
+	value, ok := o[name]
+	if !ok || value == nil {
+		return def, nil
+	}
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return def, fmt.Errorf("option %s must be an integer, got %q", name, v)
+		}
+		return i, nil
+	default:
+		return def, fmt.Errorf("option %s must be an integer, got %v", name, value)
+	}
+}
+
+// compositeVisitor applies the visitors of a declarative recipe's recipe list in order
+type compositeVisitor []compositeEntry
+
+// compositeEntry is a recipe of a recipe list together with its visitor
+type compositeEntry struct {
+	name    string
+	visitor RecipeVisitor
+}
+
+// Visit implements RecipeVisitor
+// Errors are wrapped in a recipeFrame naming the recipe of the list that produced them.
+func (c compositeVisitor) Visit(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error) {
+	current := sourceFile
+	for _, entry := range c {
+		after, err := visitRecipe(entry.visitor, ctx, current)
+		if err != nil {
+			return nil, &recipeFrame{recipe: entry.name, err: err}
+		}
+		current = after
+		if current == nil {
+			break
+		}
+	}
+	return current, nil
+}
+
+// recipeFrame records which recipe of a recipe list produced an error
+type recipeFrame struct {
+	recipe string
+	err    error
+}
+
+// Error implements the error interface
+func (f *recipeFrame) Error() string {
+	return f.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (f *recipeFrame) Unwrap() error {
+	return f.err
+}
+
+// recipePanic is the error of a recipe that panicked
+type recipePanic struct {
+	value interface{}
+	stack string
+}
+
+// Error implements the error interface
+func (p *recipePanic) Error() string {
+	return fmt.Sprintf("panic: %v", p.value)
+}
+
+// visitRecipe calls a visitor, turning a panic into a recipePanic error with the stack of the panic
+func visitRecipe(visitor RecipeVisitor, ctx *ExecutionContext, sourceFile *SourceFile) (after *SourceFile, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = &recipePanic{value: value, stack: string(debug.Stack())}
+		}
+	}()
+	return visitor.Visit(ctx, sourceFile)
+}
+
+// noopVisitor is used for recipes that have no implementation in rewrite-go
+var noopVisitor = RecipeVisitorFunc(func(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error) {
+	return sourceFile, nil
+})
+
+// buildVisitor creates the visitor of the named recipe
+// Declarative recipes from the environment take precedence over built-in recipes of the same name.
+// Recipes that are neither declared nor built in are not applied.
+func (r *Rewriter) buildVisitor(name string, options map[string]interface{}, visiting map[string]bool) (RecipeVisitor, error) {
+	if visiting[name] {
+		return nil, fmt.Errorf("recipe %s includes itself", name)
+	}
+
+	for _, declared := range r.Environment.Recipes {
+		if declared.Name != name || len(declared.RecipeList) == 0 {
+			continue
+		}
+
+		visiting[name] = true
+		defer delete(visiting, name)
+
+		var composite compositeVisitor
+		for _, entry := range declared.RecipeList {
+			visitor, err := r.buildVisitor(entry.Name, entry.Options, visiting)
+			if err != nil {
+				return nil, err
+			}
+			composite = append(composite, compositeEntry{name: entry.Name, visitor: visitor})
+		}
+		return composite, nil
+	}
+
+	if descriptor := LookupRecipe(name); descriptor != nil {
+		visitor, err := descriptor.New(RecipeOptions(options))
+		if err != nil {
+			return nil, fmt.Errorf("invalid options for recipe %s: %w", name, err)
+		}
+		return visitor, nil
+	}
+
+	return noopVisitor, nil
+}
+
+// copySourceFile returns a copy of a source file that a recipe can modify
+func copySourceFile(sourceFile *SourceFile) *SourceFile {
+	return &SourceFile{
+		Path:     sourceFile.Path,
+		Content:  sourceFile.Content,
+		Charset:  sourceFile.Charset,
+		Modified: false,
+		Mode:     sourceFile.Mode,
+
+		SearchResults: append([]SearchResult(nil), sourceFile.SearchResults...),
+	}
+}
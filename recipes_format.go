@@ -0,0 +1,122 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterRecipe(&RecipeDescriptor{
+		Name:        "org.openrewrite.java.format.TabsAndIndents",
+		DisplayName: "Tabs and indents",
+		Description: "Writes the indentation of lines with tabs or spaces as the active TabsAndIndentsStyle configures. The depth of the indentation is kept.",
+		Tags:        []string{"format"},
+		Options: []RecipeOption{
+			{Name: "filePattern", DisplayName: "File pattern", Description: "A glob limiting the files the recipe applies to.", Type: "String", Default: "**/*.java", Example: "**/*.java"},
+		},
+		EstimatedEffortPerOccurrence: 30 * time.Second,
+		New:                          newTabsAndIndents,
+	})
+
+	RegisterRecipe(&RecipeDescriptor{
+		Name:        "org.openrewrite.java.format.BlankLines",
+		DisplayName: "Blank lines",
+		Description: "Removes consecutive blank lines beyond the maximum the active BlankLinesStyle keeps.",
+		Tags:        []string{"format"},
+		Options: []RecipeOption{
+			{Name: "filePattern", DisplayName: "File pattern", Description: "A glob limiting the files the recipe applies to.", Type: "String", Default: "**/*.java", Example: "**/*.java"},
+		},
+		EstimatedEffortPerOccurrence: 10 * time.Second,
+		New:                          newBlankLines,
+	})
+}
+
+// newTabsAndIndents creates the visitor of org.openrewrite.java.format.TabsAndIndents
+func newTabsAndIndents(options RecipeOptions) (RecipeVisitor, error) {
+	filePattern := options.String("filePattern", "**/*.java")
+
+	return RecipeVisitorFunc(func(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error) {
+		if !matchesFilePattern(sourceFile.Path, filePattern) {
+			return sourceFile, nil
+		}
+		style := ctx.Styles.TabsAndIndents
+
+		lines := strings.Split(sourceFile.Content, "\n")
+		for i, line := range lines {
+			indent := len(line) - len(strings.TrimLeft(line, " \t"))
+			lines[i] = formatIndentation(line[:indent], style) + line[indent:]
+		}
+		return withContent(sourceFile, strings.Join(lines, "\n")), nil
+	}), nil
+}
+
+// formatIndentation writes the indentation of a line as a style configures it, keeping its width
+// Tabs advance to the next multiple of the tab size. With tabs, a width that is not a multiple of the
+// tab size is completed with spaces, which keeps continuation lines such as those of comments aligned.
+func formatIndentation(indent string, style TabsAndIndentsStyle) string {
+	tabSize := max(style.TabSize, 1)
+	width := 0
+	for _, c := range indent {
+		if c == '\t' {
+			width += tabSize - width%tabSize
+		} else {
+			width++
+		}
+	}
+	if !style.UseTabCharacter {
+		return strings.Repeat(" ", width)
+	}
+	return strings.Repeat("\t", width/tabSize) + strings.Repeat(" ", width%tabSize)
+}
+
+// newBlankLines creates the visitor of org.openrewrite.java.format.BlankLines
+// Without a syntax tree declarations and code cannot be told apart, so runs of blank lines are limited to
+// the larger of keepMaximum.inDeclarations and keepMaximum.inCode, and to keepMaximum.beforeEndOfBlock
+// before a line starting with a closing brace.
+func newBlankLines(options RecipeOptions) (RecipeVisitor, error) {
+	filePattern := options.String("filePattern", "**/*.java")
+
+	return RecipeVisitorFunc(func(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error) {
+		if !matchesFilePattern(sourceFile.Path, filePattern) {
+			return sourceFile, nil
+		}
+		keepMaximum := ctx.Styles.BlankLines.KeepMaximum
+		maximum := max(keepMaximum.InDeclarations, keepMaximum.InCode, 0)
+		beforeEndOfBlock := max(keepMaximum.BeforeEndOfBlock, 0)
+
+		lines := strings.Split(sourceFile.Content, "\n")
+		kept := make([]string, 0, len(lines))
+		var blank []string
+		for _, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				blank = append(blank, line)
+				continue
+			}
+			keep := maximum
+			if strings.HasPrefix(trimmed, "}") {
+				keep = beforeEndOfBlock
+			}
+			// Blank lines at the start of the file are kept as they are
+			if len(kept) == 0 {
+				keep = len(blank)
+			}
+			kept = append(kept, blank[:min(len(blank), keep)]...)
+			kept = append(kept, line)
+			blank = blank[:0]
+		}
+		kept = append(kept, blank...)
+		return withContent(sourceFile, strings.Join(kept, "\n")), nil
+	}), nil
+}
+
+// withContent returns sourceFile with new content, or sourceFile itself if the content is unchanged
+func withContent(sourceFile *SourceFile, content string) *SourceFile {
+	if content == sourceFile.Content {
+		return sourceFile
+	}
+	after := copySourceFile(sourceFile)
+	after.Content = content
+	after.Modified = true
+	return after
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DependenciesInUseRow is a row of the table listing the dependencies matched by DependencyInsight
+type DependenciesInUseRow struct {
+	ProjectName string `column:"projectName" displayName:"Project name" description:"The name of the project that contains the dependency."`
+	SourcePath  string `column:"sourcePath" displayName:"Source path" description:"The path of the pom.xml declaring the dependency."`
+	GroupID     string `column:"groupId" displayName:"Group" description:"The first part of a dependency coordinate, e.g. com.google.guava."`
+	ArtifactID  string `column:"artifactId" displayName:"Artifact" description:"The second part of a dependency coordinate, e.g. guava."`
+	Version     string `column:"version" displayName:"Version" description:"The resolved version, or empty if it is managed elsewhere."`
+	Scope       string `column:"scope" displayName:"Scope" description:"Dependency scope, compile if none is declared."`
+	Managed     bool   `column:"managed" displayName:"Managed" description:"Whether the dependency is declared in dependencyManagement."`
+}
+
+// dependenciesInUse is filled by org.openrewrite.maven.search.DependencyInsight
+var dependenciesInUse = NewDataTable[DependenciesInUseRow](
+	"org.openrewrite.maven.table.DependenciesInUse",
+	"Dependencies in use",
+	"Direct dependencies in use in Maven projects.",
+)
+
+func init() {
+	RegisterRecipe(&RecipeDescriptor{
+		Name:        "org.openrewrite.maven.search.DependencyInsight",
+		DisplayName: "Maven dependency insight",
+		Description: "Find direct dependencies of Maven projects matching a group and artifact pattern.",
+		Tags:        []string{"maven", "search"},
+		Options: []RecipeOption{
+			{Name: "groupIdPattern", DisplayName: "Group pattern", Description: "Group glob pattern used to match dependencies.", Type: "String", Required: true, Example: "com.fasterxml.jackson.*"},
+			{Name: "artifactIdPattern", DisplayName: "Artifact pattern", Description: "Artifact glob pattern used to match dependencies.", Type: "String", Required: true, Example: "jackson-*"},
+			{Name: "scope", DisplayName: "Scope", Description: "Match dependencies with the specified scope. All scopes are matched if not set.", Type: "String", Example: "compile"},
+		},
+		DataTables: []DataTableDescriptor{dependenciesInUse.Descriptor()},
+		New:        newDependencyInsight,
+	})
+}
+
+// pomProject is the part of a Maven pom.xml the Maven recipes read
+type pomProject struct {
+	GroupID    string        `xml:"groupId"`
+	ArtifactID string        `xml:"artifactId"`
+	Version    string        `xml:"version"`
+	Parent     pomDependency `xml:"parent"`
+	Properties pomProperties `xml:"properties"`
+}
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+}
+
+// pomDependencyDeclaration is a dependency together with the region of the pom declaring it
+type pomDependencyDeclaration struct {
+	pomDependency
+	Managed bool
+	Start   int
+	End     int
+}
+
+// pomDependencies returns the direct and managed dependencies of a pom in document order
+// Dependencies of plugins are not included.
+func pomDependencies(content string) ([]pomDependencyDeclaration, error) {
+	var declarations []pomDependencyDeclaration
+	var elements []string
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return declarations, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			parent := strings.Join(elements, "/")
+			if element.Name.Local == "dependency" && (parent == "project/dependencies" || parent == "project/dependencyManagement/dependencies") {
+				declaration := pomDependencyDeclaration{Managed: parent != "project/dependencies", Start: start}
+				err = decoder.DecodeElement(&declaration.pomDependency, &element)
+				if err != nil {
+					return nil, err
+				}
+				declaration.End = int(decoder.InputOffset())
+				declarations = append(declarations, declaration)
+				continue
+			}
+			elements = append(elements, element.Name.Local)
+		case xml.EndElement:
+			elements = elements[:len(elements)-1]
+		}
+	}
+}
+
+type pomProperties struct {
+	Entries []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+// pomPropertyReference matches ${property} placeholders
+var pomPropertyReference = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// resolve replaces the properties a pom declares, leaving unknown properties as they are
+func (p *pomProject) resolve(value string) string {
+	return pomPropertyReference.ReplaceAllStringFunc(value, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		switch name {
+		case "project.version", "version":
+			if p.Version != "" {
+				return p.Version
+			}
+			return p.Parent.Version
+		case "project.groupId", "groupId":
+			if p.GroupID != "" {
+				return p.GroupID
+			}
+			return p.Parent.GroupID
+		}
+		for _, entry := range p.Properties.Entries {
+			if entry.XMLName.Local == name {
+				return strings.TrimSpace(entry.Value)
+			}
+		}
+		return ref
+	})
+}
+
+// isPom reports whether a source file is a Maven pom
+func isPom(sourceFile *SourceFile) bool {
+	return filepath.Base(sourceFile.Path) == "pom.xml"
+}
+
+// newDependencyInsight creates the visitor of org.openrewrite.maven.search.DependencyInsight
+// Matching dependencies are marked as search results and added to the DependenciesInUse data table.
+func newDependencyInsight(options RecipeOptions) (RecipeVisitor, error) {
+	groupIDPattern := options.String("groupIdPattern", "")
+	artifactIDPattern := options.String("artifactIdPattern", "")
+	if groupIDPattern == "" || artifactIDPattern == "" {
+		return nil, fmt.Errorf("options groupIdPattern and artifactIdPattern are required")
+	}
+	for _, pattern := range []string{groupIDPattern, artifactIDPattern} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	scope := options.String("scope", "")
+
+	return RecipeVisitorFunc(func(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error) {
+		if !isPom(sourceFile) {
+			return sourceFile, nil
+		}
+
+		var pom pomProject
+		err := xml.Unmarshal([]byte(sourceFile.Content), &pom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pom: %w", err)
+		}
+
+		declarations, err := pomDependencies(sourceFile.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pom: %w", err)
+		}
+
+		var regions []SearchRegion
+		for _, dependency := range declarations {
+			groupID := pom.resolve(strings.TrimSpace(dependency.GroupID))
+			artifactID := pom.resolve(strings.TrimSpace(dependency.ArtifactID))
+			version := pom.resolve(strings.TrimSpace(dependency.Version))
+			dependencyScope := strings.TrimSpace(dependency.Scope)
+			if dependencyScope == "" {
+				dependencyScope = "compile"
+			}
+
+			if matched, _ := path.Match(groupIDPattern, groupID); !matched {
+				continue
+			}
+			if matched, _ := path.Match(artifactIDPattern, artifactID); !matched {
+				continue
+			}
+			if scope != "" && scope != dependencyScope {
+				continue
+			}
+
+			dependenciesInUse.InsertRow(ctx, DependenciesInUseRow{
+				ProjectName: pom.resolve(strings.TrimSpace(pom.ArtifactID)),
+				SourcePath:  filepath.ToSlash(sourceFile.Path),
+				GroupID:     groupID,
+				ArtifactID:  artifactID,
+				Version:     version,
+				Scope:       dependencyScope,
+				Managed:     dependency.Managed,
+			})
+
+			coordinates := groupID + ":" + artifactID
+			if version != "" {
+				coordinates += ":" + version
+			}
+			regions = append(regions, SearchRegion{Start: dependency.Start, End: dependency.End, Message: coordinates})
+		}
+
+		if len(regions) == 0 {
+			return sourceFile, nil
+		}
+		return markSearchResults(sourceFile, "org.openrewrite.maven.search.DependencyInsight", regions...), nil
+	}), nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterRecipe(&RecipeDescriptor{
+		Name:        "org.openrewrite.text.FindAndReplace",
+		DisplayName: "Find and replace",
+		Description: "Textual find and replace, optionally interpreting the search query as a regular expression.",
+		Tags:        []string{"text"},
+		Options: []RecipeOption{
+			{Name: "find", DisplayName: "Find", Description: "The text to find.", Type: "String", Required: true, Example: "blacklist"},
+			{Name: "replace", DisplayName: "Replace", Description: "The replacement text for find. With regex, $1 refers to the first capturing group.", Type: "String", Default: "", Example: "denylist"},
+			{Name: "regex", DisplayName: "Regex", Description: "Whether find is a regular expression.", Type: "Boolean", Default: false},
+			{Name: "caseSensitive", DisplayName: "Case sensitive", Description: "Whether the search is case sensitive.", Type: "Boolean", Default: true},
+			{Name: "filePattern", DisplayName: "File pattern", Description: "A glob limiting the files the recipe applies to.", Type: "String", Example: "**/*.java"},
+		},
+		EstimatedEffortPerOccurrence: time.Minute,
+		New:                          newFindAndReplace,
+	})
+
+	RegisterRecipe(&RecipeDescriptor{
+		Name:        "org.openrewrite.text.Find",
+		DisplayName: "Find text",
+		Description: "Textual search, optionally interpreting the search query as a regular expression. Matches are reported as search results and files are not changed.",
+		Tags:        []string{"text", "search"},
+		Options: []RecipeOption{
+			{Name: "find", DisplayName: "Find", Description: "The text to find.", Type: "String", Required: true, Example: "TODO"},
+			{Name: "regex", DisplayName: "Regex", Description: "Whether find is a regular expression.", Type: "Boolean", Default: false},
+			{Name: "caseSensitive", DisplayName: "Case sensitive", Description: "Whether the search is case sensitive.", Type: "Boolean", Default: true},
+			{Name: "filePattern", DisplayName: "File pattern", Description: "A glob limiting the files the recipe applies to.", Type: "String", Example: "**/*.java"},
+		},
+		DataTables: []DataTableDescriptor{textMatches.Descriptor()},
+		New:        newFind,
+	})
+}
+
+// TextMatchesRow is a row of the table listing the matches of org.openrewrite.text.Find
+type TextMatchesRow struct {
+	SourceFile string `column:"sourceFile" displayName:"Source file" description:"The file that contains the match."`
+	Line       int    `column:"line" displayName:"Line" description:"The line of the match."`
+	Column     int    `column:"column" displayName:"Column" description:"The column of the match."`
+	Match      string `column:"match" displayName:"Match" description:"The matched text."`
+}
+
+// textMatches is filled by org.openrewrite.text.Find
+var textMatches = NewDataTable[TextMatchesRow](
+	"org.openrewrite.text.table.TextMatches",
+	"Text matches",
+	"The text matched by a search.",
+)
+
+// newFindAndReplace creates the visitor of org.openrewrite.text.FindAndReplace
+func newFindAndReplace(options RecipeOptions) (RecipeVisitor, error) {
+	pattern, err := textPattern(options)
+	if err != nil {
+		return nil, err
+	}
+	isRegex, _ := options.Bool("regex", false)
+	replace := options.String("replace", "")
+	filePattern := options.String("filePattern", "")
+
+	return RecipeVisitorFunc(func(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error) {
+		if !matchesFilePattern(sourceFile.Path, filePattern) || !pattern.MatchString(sourceFile.Content) {
+			return sourceFile, nil
+		}
+		after := copySourceFile(sourceFile)
+		if isRegex {
+			after.Content = pattern.ReplaceAllString(sourceFile.Content, replace)
+		} else {
+			after.Content = pattern.ReplaceAllLiteralString(sourceFile.Content, replace)
+		}
+		after.Modified = after.Content != sourceFile.Content
+		return after, nil
+	}), nil
+}
+
+// newFind creates the visitor of org.openrewrite.text.Find
+func newFind(options RecipeOptions) (RecipeVisitor, error) {
+	pattern, err := textPattern(options)
+	if err != nil {
+		return nil, err
+	}
+	filePattern := options.String("filePattern", "")
+
+	return RecipeVisitorFunc(func(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error) {
+		if !matchesFilePattern(sourceFile.Path, filePattern) {
+			return sourceFile, nil
+		}
+
+		matches := pattern.FindAllStringIndex(sourceFile.Content, -1)
+		if len(matches) == 0 {
+			return sourceFile, nil
+		}
+
+		var regions []SearchRegion
+		for _, match := range matches {
+			regions = append(regions, SearchRegion{
+				Start:   match[0],
+				End:     match[1],
+				Message: fmt.Sprintf("found %q", sourceFile.Content[match[0]:match[1]]),
+			})
+		}
+		marked := markSearchResults(sourceFile, "org.openrewrite.text.Find", regions...)
+
+		for i, result := range marked.SearchResults[len(sourceFile.SearchResults):] {
+			textMatches.InsertRow(ctx, TextMatchesRow{
+				SourceFile: result.Path,
+				Line:       result.Line,
+				Column:     result.Column,
+				Match:      sourceFile.Content[matches[i][0]:matches[i][1]],
+			})
+		}
+		return marked, nil
+	}), nil
+}
+
+// textPattern compiles the find, regex and caseSensitive options of a text recipe
+func textPattern(options RecipeOptions) (*regexp.Regexp, error) {
+	find := options.String("find", "")
+	if find == "" {
+		return nil, fmt.Errorf("option find is required")
+	}
+	isRegex, err := options.Bool("regex", false)
+	if err != nil {
+		return nil, err
+	}
+	caseSensitive, err := options.Bool("caseSensitive", true)
+	if err != nil {
+		return nil, err
+	}
+
+	expr := find
+	if !isRegex {
+		expr = regexp.QuoteMeta(find)
+	}
+	if !caseSensitive {
+		expr = "(?i)" + expr
+	}
+	pattern, err := regexp.Compile("(?m)" + expr)
+	if err != nil {
+		return nil, fmt.Errorf("option find is not a valid regular expression: %w", err)
+	}
+	return pattern, nil
+}
+
+// matchesFilePattern reports whether a source path matches an optional file pattern
+func matchesFilePattern(path, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	if (&Rewriter{}).matchesPatterns(filepath.ToSlash(path), []string{pattern}) {
+		return true
+	}
+	// **/*.java matches Java files in any directory
+	if rest, ok := strings.CutPrefix(pattern, "**/"); ok && !strings.Contains(rest, "/") {
+		matched, _ := filepath.Match(rest, filepath.Base(path))
+		return matched
+	}
+	return false
+}
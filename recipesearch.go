@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// recipeSnippetRadius is the number of characters shown on each side of a match in a snippet
+const recipeSnippetRadius = 40
+
+// Weights of a query term matching each field of a recipe
+// A term that matches the last segment of a recipe name exactly ranks highest, a fuzzy match of the name lowest.
+const (
+	searchWeightSimpleName  = 20
+	searchWeightName        = 10
+	searchWeightDisplayName = 8
+	searchWeightTag         = 6
+	searchWeightOption      = 4
+	searchWeightDescription = 3
+	searchWeightFuzzy       = 1
+)
+
+// RecipeSearchResult is a recipe that matched a discover search, with the parts of it that matched
+type RecipeSearchResult struct {
+	Name        string              `json:"name"`
+	DisplayName string              `json:"displayName,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Score       int                 `json:"score"`
+	Matches     []RecipeSearchMatch `json:"matches"`
+}
+
+// RecipeSearchMatch is a field of a recipe that a query term matched
+// The snippet is the part of the field around the match, Start and End the position of the match in it.
+type RecipeSearchMatch struct {
+	Term    string `json:"term"`
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+}
+
+// searchRecipes ranks the recipes matching every term of a query, best matches first
+// Terms are matched ignoring case against the name, display name, tags, option names and description of
+// recipes. A term matches a name fuzzily when its characters appear in the name in order.
+func searchRecipes(recipes []*AvailableRecipe, query string) []*RecipeSearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []*RecipeSearchResult
+	for _, recipe := range recipes {
+		if recipe.Unavailable {
+			continue
+		}
+		result := &RecipeSearchResult{
+			Name:        recipe.Name,
+			DisplayName: recipe.DisplayName,
+			Description: recipe.Description,
+			Tags:        recipe.Tags,
+		}
+		for _, term := range terms {
+			weight, match := matchRecipeTerm(recipe, term)
+			if match == nil {
+				result = nil
+				break
+			}
+			result.Score += weight
+			result.Matches = append(result.Matches, *match)
+		}
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return strings.ToLower(results[i].Name) < strings.ToLower(results[j].Name)
+	})
+	return results
+}
+
+// matchRecipeTerm returns the weight and the match of the field of a recipe that a term matches best
+func matchRecipeTerm(recipe *AvailableRecipe, term string) (int, *RecipeSearchMatch) {
+	simpleName := recipe.Name[strings.LastIndex(recipe.Name, ".")+1:]
+	if strings.EqualFold(simpleName, term) {
+		return searchWeightSimpleName, newRecipeSearchMatch(term, "name", recipe.Name)
+	}
+
+	fields := []struct {
+		name   string
+		weight int
+		values []string
+	}{
+		{"name", searchWeightName, []string{recipe.Name}},
+		{"displayName", searchWeightDisplayName, []string{recipe.DisplayName}},
+		{"tags", searchWeightTag, recipe.Tags},
+		{"options", searchWeightOption, recipeOptionNames(recipe)},
+		{"description", searchWeightDescription, []string{recipe.Description}},
+	}
+	for _, field := range fields {
+		for _, value := range field.values {
+			if match := newRecipeSearchMatch(term, field.name, value); match != nil {
+				return field.weight, match
+			}
+		}
+	}
+
+	if fuzzyMatch(strings.ToLower(recipe.Name), term) {
+		return searchWeightFuzzy, &RecipeSearchMatch{Term: term, Field: "name", Snippet: recipe.Name}
+	}
+	return 0, nil
+}
+
+// recipeOptionNames returns the names of the options of a recipe
+func recipeOptionNames(recipe *AvailableRecipe) []string {
+	names := make([]string, 0, len(recipe.Options))
+	for _, option := range recipe.Options {
+		names = append(names, option.Name)
+	}
+	return names
+}
+
+// newRecipeSearchMatch returns the match of a term in the value of a field, or nil if it does not contain it
+func newRecipeSearchMatch(term, field, value string) *RecipeSearchMatch {
+	index := strings.Index(strings.ToLower(value), term)
+	if index < 0 {
+		return nil
+	}
+
+	start := max(index-recipeSnippetRadius, 0)
+	end := min(index+len(term)+recipeSnippetRadius, len(value))
+	// Snippets start and end on whole words
+	if start > 0 {
+		if space := strings.IndexByte(value[start:index], ' '); space >= 0 {
+			start += space + 1
+		}
+	}
+	if end < len(value) {
+		if space := strings.LastIndexByte(value[index+len(term):end], ' '); space >= 0 {
+			end = index + len(term) + space
+		}
+	}
+
+	snippet := value[start:end]
+	offset := index - start
+	if start > 0 {
+		snippet = "..." + snippet
+		offset += len("...")
+	}
+	if end < len(value) {
+		snippet += "..."
+	}
+	return &RecipeSearchMatch{Term: term, Field: field, Snippet: snippet, Start: offset, End: offset + len(term)}
+}
+
+// fuzzyMatch reports whether the characters of term appear in value in order
+func fuzzyMatch(value, term string) bool {
+	for _, c := range term {
+		index := strings.IndexRune(value, c)
+		if index < 0 {
+			return false
+		}
+		value = value[index+len(string(c)):]
+	}
+	return true
+}
+
+// writeRecipeSearchResults writes the results of a discover search as text
+// The matched part of each snippet is enclosed in brackets.
+func writeRecipeSearchResults(w io.Writer, query string, results []*RecipeSearchResult) {
+	fmt.Fprintf(w, "Found %d recipes matching %q:\n", len(results), query)
+	for _, result := range results {
+		fmt.Fprintf(w, "%s%s (score %d)\n", discoverIndent, result.Name, result.Score)
+		if result.DisplayName != "" {
+			fmt.Fprintf(w, "%s%s\n", strings.Repeat(discoverIndent, 2), result.DisplayName)
+		}
+		for _, match := range result.Matches {
+			snippet := match.Snippet
+			if match.End > match.Start {
+				snippet = snippet[:match.Start] + "[" + snippet[match.Start:match.End] + "]" + snippet[match.End:]
+			}
+			fmt.Fprintf(w, "%s%s: %s\n", strings.Repeat(discoverIndent, 2), match.Field, snippet)
+		}
+	}
+}
+
+// writeRecipeSearchResultsJSON writes the results of a discover search as a JSON array
+func writeRecipeSearchResultsJSON(w io.Writer, results []*RecipeSearchResult) error {
+	if results == nil {
+		results = []*RecipeSearchResult{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
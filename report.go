@@ -0,0 +1,400 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reportWriters maps the supported report formats to the functions writing them
+var reportWriters = map[string]func(w io.Writer, report *RunReport) error{
+	"html":  writeHTMLReport,
+	"json":  writeJSONReport,
+	"junit": writeJUnitReport,
+	"sarif": writeSARIFReport,
+}
+
+// ReportTarget is a report format and the file it is written to
+type ReportTarget struct {
+	Format string
+	Path   string
+}
+
+// parseReportTargets parses report specifications of the form format=path
+func parseReportTargets(specs []string) ([]ReportTarget, error) {
+	var targets []ReportTarget
+	for _, spec := range CleanStringSlice(specs) {
+		format, path, ok := strings.Cut(spec, "=")
+		format = strings.ToLower(strings.TrimSpace(format))
+		path = strings.TrimSpace(path)
+		if !ok || format == "" || path == "" {
+			return nil, fmt.Errorf("invalid report %q, expected format=path", spec)
+		}
+		if _, ok := reportWriters[format]; !ok {
+			return nil, fmt.Errorf("unknown report format %q, supported formats are: %s", format, strings.Join(reportFormats(), ", "))
+		}
+		targets = append(targets, ReportTarget{Format: format, Path: path})
+	}
+	return targets, nil
+}
+
+// reportFormats returns the names of the supported report formats
+func reportFormats() []string {
+	var formats []string
+	for format := range reportWriters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// RunReport is the structured report of a run
+// It is built from a ResultsContainer and serialized by the report writers.
+type RunReport struct {
+	Tool        string         `json:"tool"`
+	Version     string         `json:"version"`
+	Command     string         `json:"command"`
+	StartedAt   time.Time      `json:"startedAt"`
+	DurationMs  int64          `json:"durationMs"`
+	ProjectRoot string         `json:"projectRoot"`
+	Config      *Config        `json:"config"`
+	RecipeTree  []*RecipeNode  `json:"activeRecipes"`
+	Results     []ResultReport `json:"results"`
+	Recipes     []RecipeReport `json:"recipes"`
+	Modules     []ModuleReport `json:"modules"`
+	Parsers     []ParserReport `json:"parsers"`
+	Searches    []SearchResult `json:"searchResults"`
+	Errors      []ErrorReport  `json:"errors,omitempty"`
+	Interrupted bool           `json:"interrupted,omitempty"`
+	Summary     ReportSummary  `json:"summary"`
+
+	// Container is the results model the report was built from
+	Container *ResultsContainer `json:"-"`
+	// Environment holds the recipes and styles of the run
+	Environment *Environment `json:"-"`
+}
+
+// RecipeNode is a recipe together with the recipes it is composed of
+type RecipeNode struct {
+	Name        string        `json:"name"`
+	DisplayName string        `json:"displayName,omitempty"`
+	Description string        `json:"description,omitempty"`
+	RecipeList  []*RecipeNode `json:"recipeList,omitempty"`
+}
+
+// ResultReport describes a single changed file
+type ResultReport struct {
+	Category         string   `json:"category"`
+	BeforePath       string   `json:"beforePath,omitempty"`
+	AfterPath        string   `json:"afterPath,omitempty"`
+	Recipes          []string `json:"recipes"`
+	TimeSavedSeconds float64  `json:"timeSavedSeconds"`
+	DiffStats
+}
+
+// RecipeReport describes the execution of a single recipe
+type RecipeReport struct {
+	Name             string   `json:"name"`
+	DurationMs       float64  `json:"durationMs"`
+	FilesVisited     int      `json:"filesVisited"`
+	FilesChanged     int      `json:"filesChanged"`
+	SearchResults    int      `json:"searchResults"`
+	TimeSavedSeconds float64  `json:"timeSavedSeconds"`
+	AllocatedBytes   uint64   `json:"allocatedBytes,omitempty"`
+	Errors           []string `json:"errors,omitempty"`
+}
+
+// ErrorReport describes an error produced while processing a source file
+type ErrorReport struct {
+	Path        string   `json:"path,omitempty"`
+	Recipe      string   `json:"recipe,omitempty"`
+	RecipeStack []string `json:"recipeStack,omitempty"`
+	Message     string   `json:"message"`
+	Stack       string   `json:"stack,omitempty"`
+}
+
+// ParserReport describes the reading and parsing of one kind of source file
+type ParserReport struct {
+	Name           string  `json:"name"`
+	DurationMs     float64 `json:"durationMs"`
+	Files          int     `json:"files"`
+	Bytes          int64   `json:"bytes"`
+	AllocatedBytes uint64  `json:"allocatedBytes,omitempty"`
+}
+
+// ModuleReport holds the changes made to a single module
+type ModuleReport struct {
+	Module           string  `json:"module"`
+	FilesChanged     int     `json:"filesChanged"`
+	TimeSavedSeconds float64 `json:"timeSavedSeconds"`
+}
+
+// moduleMarkers are the build files that mark the root of a module
+var moduleMarkers = []string{"pom.xml", "build.gradle", "build.gradle.kts", "go.mod", "package.json"}
+
+// moduleOf returns the module a file belongs to: the nearest directory above it that holds a build file
+// Files that are not part of a nested module belong to the root module ".".
+func moduleOf(projectRoot, path string, cache map[string]string) string {
+	dir := filepath.Dir(path)
+	var visited []string
+	module := "."
+
+	for dir != "." && dir != string(filepath.Separator) && dir != "" {
+		if cached, ok := cache[dir]; ok {
+			module = cached
+			break
+		}
+		visited = append(visited, dir)
+
+		found := false
+		for _, marker := range moduleMarkers {
+			if _, err := os.Stat(filepath.Join(projectRoot, dir, marker)); err == nil {
+				found = true
+				break
+			}
+		}
+		if found {
+			module = filepath.ToSlash(dir)
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	for _, dir := range visited {
+		cache[dir] = module
+	}
+	return module
+}
+
+// ReportSummary holds the totals of a run
+type ReportSummary struct {
+	Generated        int     `json:"generated"`
+	Deleted          int     `json:"deleted"`
+	Moved            int     `json:"moved"`
+	Refactored       int     `json:"refactored"`
+	Conflicts        int     `json:"conflicts"`
+	SearchResults    int     `json:"searchResults"`
+	TimeSavedSeconds float64 `json:"timeSavedSeconds"`
+	DiffStats
+}
+
+// NewRunReport builds the report of a run from its results
+func NewRunReport(command string, startedAt time.Time, config *Config, env *Environment, results *ResultsContainer) *RunReport {
+	report := &RunReport{
+		Tool:        "rewrite-go",
+		Version:     version,
+		Command:     command,
+		StartedAt:   startedAt,
+		DurationMs:  time.Since(startedAt).Milliseconds(),
+		ProjectRoot: results.ProjectRoot,
+		Config:      config,
+		RecipeTree:  []*RecipeNode{},
+		Results:     []ResultReport{},
+		Recipes:     []RecipeReport{},
+		Modules:     []ModuleReport{},
+		Parsers:     []ParserReport{},
+		Searches:    []SearchResult{},
+		Interrupted: results.Interrupted,
+		Container:   results,
+		Environment: env,
+	}
+
+	if env != nil {
+		for _, recipe := range env.ActiveRecipes {
+			report.RecipeTree = append(report.RecipeTree, buildRecipeNode(describeRecipe(env, recipe.Name), env, map[string]bool{}))
+		}
+	}
+
+	categories := []struct {
+		name    string
+		results []Result
+		count   *int
+	}{
+		{changeGenerated, results.Generated, &report.Summary.Generated},
+		{changeDeleted, results.Deleted, &report.Summary.Deleted},
+		{changeMoved, results.Moved, &report.Summary.Moved},
+		{changeRefactored, results.RefactoredInPlace, &report.Summary.Refactored},
+	}
+	for _, category := range categories {
+		for _, result := range category.results {
+			entry := ResultReport{
+				Category:         category.name,
+				Recipes:          result.RecipesThatMadeChanges,
+				TimeSavedSeconds: result.TimeSaved.Seconds(),
+				DiffStats:        resultDiffStats(result),
+			}
+			if result.Before != nil {
+				entry.BeforePath = filepath.ToSlash(result.Before.Path)
+			}
+			if result.After != nil {
+				entry.AfterPath = filepath.ToSlash(result.After.Path)
+			}
+
+			report.Results = append(report.Results, entry)
+			report.Summary.TimeSavedSeconds += entry.TimeSavedSeconds
+			report.Summary.Additions += entry.Additions
+			report.Summary.Deletions += entry.Deletions
+			*category.count++
+		}
+	}
+	report.Summary.Conflicts = len(results.Conflicts)
+	report.Searches = append(report.Searches, results.SearchResults...)
+	report.Summary.SearchResults = len(results.SearchResults)
+
+	for _, stats := range results.RecipeStats {
+		entry := RecipeReport{
+			Name:             stats.Name,
+			DurationMs:       float64(stats.Duration.Microseconds()) / 1000,
+			FilesVisited:     stats.FilesVisited,
+			FilesChanged:     stats.FilesChanged,
+			SearchResults:    stats.SearchResults,
+			TimeSavedSeconds: stats.TimeSaved.Seconds(),
+			AllocatedBytes:   stats.AllocatedBytes,
+		}
+		for _, err := range stats.Errors {
+			entry.Errors = append(entry.Errors, err.Error())
+		}
+		report.Recipes = append(report.Recipes, entry)
+	}
+
+	for _, stats := range results.ParserStats {
+		report.Parsers = append(report.Parsers, ParserReport{
+			Name:           stats.Name,
+			DurationMs:     float64(stats.Duration.Microseconds()) / 1000,
+			Files:          stats.Files,
+			Bytes:          stats.Bytes,
+			AllocatedBytes: stats.AllocatedBytes,
+		})
+	}
+
+	for _, module := range timeSavedByModule(results.ProjectRoot, results) {
+		report.Modules = append(report.Modules, ModuleReport{
+			Module:           module.Module,
+			FilesChanged:     module.FilesChanged,
+			TimeSavedSeconds: module.TimeSaved.Seconds(),
+		})
+	}
+
+	for _, err := range results.Errors {
+		entry := ErrorReport{Message: err.Error()}
+		var recipeErr *RecipeError
+		if errors.As(err, &recipeErr) {
+			entry = ErrorReport{
+				Path:        filepath.ToSlash(recipeErr.Path),
+				Recipe:      recipeErr.Recipe,
+				RecipeStack: recipeErr.RecipeStack,
+				Message:     recipeErr.Err.Error(),
+				Stack:       recipeErr.Stack,
+			}
+		}
+		report.Errors = append(report.Errors, entry)
+	}
+
+	return report
+}
+
+// buildRecipeNode expands the recipe list of a recipe using the recipes declared in the environment
+func buildRecipeNode(recipe Recipe, env *Environment, visiting map[string]bool) *RecipeNode {
+	node := &RecipeNode{
+		Name:        recipe.Name,
+		DisplayName: recipe.DisplayName,
+		Description: recipe.Description,
+	}
+
+	// Guard against recipes that include themselves
+	if visiting[recipe.Name] {
+		return node
+	}
+	visiting[recipe.Name] = true
+	defer delete(visiting, recipe.Name)
+
+	for _, entry := range recipe.RecipeList {
+		node.RecipeList = append(node.RecipeList, buildRecipeNode(describeRecipe(env, entry.Name), env, visiting))
+	}
+
+	return node
+}
+
+// describeRecipe returns the declaration of a recipe, or a recipe described by its built-in descriptor
+// Activations such as recipeList entries are skipped, they only carry the name and options of a recipe.
+func describeRecipe(env *Environment, name string) Recipe {
+	if env != nil {
+		for _, recipe := range env.Recipes {
+			if recipe.declared && recipe.Name == name {
+				return recipe
+			}
+		}
+	}
+	if descriptor := LookupRecipe(name); descriptor != nil {
+		return Recipe{Name: name, DisplayName: descriptor.DisplayName, Description: descriptor.Description, Tags: descriptor.Tags}
+	}
+	return Recipe{Name: name}
+}
+
+// resultDiffStats counts the lines a result adds and removes
+func resultDiffStats(result Result) DiffStats {
+	var before, after string
+	if result.Before != nil {
+		before = result.Before.Content
+	}
+	if result.After != nil {
+		after = result.After.Content
+	}
+	return Stats(DiffText(before, after))
+}
+
+// writeJSONReport writes the report as indented JSON
+func writeJSONReport(w io.Writer, report *RunReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// writeReports writes every configured report of a run
+func (r *Runner) writeReports(command string, startedAt time.Time, results *ResultsContainer) error {
+	targets, err := parseReportTargets(r.Rewriter.Config.Reports)
+	if err != nil {
+		return configError(err)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	report := NewRunReport(command, startedAt, r.Rewriter.Config, r.Rewriter.Environment, results)
+	for _, target := range targets {
+		err := writeReportFile(target, report)
+		if err != nil {
+			return fmt.Errorf("failed to write %s report: %w", target.Format, err)
+		}
+		r.Logger.Info("Wrote report", "format", target.Format, "path", target.Path)
+	}
+
+	return nil
+}
+
+// writeReportFile writes a report to the file of the target
+func writeReportFile(target ReportTarget, report *RunReport) error {
+	err := os.MkdirAll(filepath.Dir(target.Path), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(target.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = reportWriters[target.Format](file, report)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
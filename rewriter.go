@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -18,14 +23,30 @@ type Rewriter struct {
 	Config      *Config
 	Environment *Environment
 	BaseDir     string
+
+	// Progress reports the progress of discovery and processing, if set
+	Progress *Progress
 }
 
 // Environment represents the rewrite environment with loaded recipes and configurations
 // This mirrors the Environment class from the Java version
 type Environment struct {
+	// Recipes holds every recipe declared in the configuration, active or not
+	Recipes       []Recipe
 	ActiveRecipes []Recipe
-	ActiveStyles  []Style
-	Properties    map[string]string
+	// Styles holds every style declared in the configuration, active or not
+	Styles       []Style
+	ActiveStyles []Style
+	Properties   map[string]string
+
+	// StyleSet holds the style configurations of the active styles, which recipes format code with
+	StyleSet *StyleSet
+
+	// Categories holds the recipe categories declared in the configuration
+	Categories []Category
+
+	// Issues are the problems found in the configuration that did not prevent loading it
+	Issues []ValidationIssue
 }
 
 // Recipe represents a rewrite recipe
@@ -34,24 +55,52 @@ type Recipe struct {
 	DisplayName string                 `yaml:"displayName,omitempty"`
 	Description string                 `yaml:"description,omitempty"`
 	Tags        []string               `yaml:"tags,omitempty"`
-	RecipeList  []string               `yaml:"recipeList,omitempty"`
+	RecipeList  []RecipeListEntry      `yaml:"recipeList,omitempty"`
 	Config      map[string]interface{} `yaml:",inline"`
+
+	// EstimatedEffortPerOccurrence is the time a developer would spend on a change, e.g. 10m or PT10M
+	EstimatedEffortPerOccurrence string `yaml:"estimatedEffortPerOccurrence,omitempty"`
+
+	// Options configure the recipe when it is activated from a recipeList with options
+	Options map[string]interface{} `yaml:"-"`
+
+	// declared is set for recipes defined in a recipes section rather than only activated
+	declared bool
+	// position is where the recipe is defined or activated in the configuration, for validation issues
+	position configPosition
 }
 
 // Style represents a rewrite style configuration
+// This mirrors the NamedStyles from the Java version, a named list of style configurations.
 type Style struct {
-	Name   string                 `yaml:"name"`
-	Config map[string]interface{} `yaml:",inline"`
+	Name         string                 `yaml:"name"`
+	DisplayName  string                 `yaml:"displayName,omitempty"`
+	Description  string                 `yaml:"description,omitempty"`
+	StyleConfigs []StyleConfig          `yaml:"styleConfigs,omitempty"`
+	Config       map[string]interface{} `yaml:",inline"`
+
+	// declared is set for styles defined in a styles section or a style document rather than only activated
+	declared bool
+	// position is where the style is defined or activated in the configuration, for validation issues
+	position configPosition
 }
 
+// Types of configuration documents
+const (
+	RecipeSpecType   = "specs.openrewrite.org/v1beta/recipe"
+	StyleSpecType    = "specs.openrewrite.org/v1beta/style"
+	CategorySpecType = "specs.openrewrite.org/v1beta/category"
+)
+
 // RewriteConfig represents the structure of rewrite.yml
 type RewriteConfig struct {
-	Type        string   `yaml:"type,omitempty"`
-	Recipes     []Recipe `yaml:"recipes,omitempty"`
-	Styles      []Style  `yaml:"styles,omitempty"`
-	RecipeList  []string `yaml:"recipeList,omitempty"`
-	StyleList   []string `yaml:"styleList,omitempty"`
-	Description string   `yaml:"description,omitempty"`
+	Type        string            `yaml:"type,omitempty"`
+	Name        string            `yaml:"name,omitempty"`
+	Recipes     []Recipe          `yaml:"recipes,omitempty"`
+	Styles      []Style           `yaml:"styles,omitempty"`
+	RecipeList  []RecipeListEntry `yaml:"recipeList,omitempty"`
+	StyleList   []string          `yaml:"styleList,omitempty"`
+	Description string            `yaml:"description,omitempty"`
 }
 
 // Result represents the result of a rewrite operation
@@ -61,6 +110,18 @@ type Result struct {
 	After                  *SourceFile
 	RecipesThatMadeChanges []string
 	TimeSaved              time.Duration
+
+	// recipeTimeSaved is the time saved by each recipe that made changes, by the index of its statistics
+	recipeTimeSaved map[int]time.Duration
+	// edits are the changes of the recipes that made changes, used to tell which recipe changed which lines
+	edits []recipeEdit
+}
+
+// recipeEdit is the change one recipe made to a source file
+type recipeEdit struct {
+	recipe string
+	before *SourceFile
+	after  *SourceFile
 }
 
 // SourceFile represents a source file being processed
@@ -69,6 +130,15 @@ type SourceFile struct {
 	Content  string
 	Charset  string
 	Modified bool
+	Mode     os.FileMode
+
+	// Checksum and ModTime describe the file as it was read from disk
+	// They are used to detect modifications made by other tools before results are written back
+	Checksum string
+	ModTime  time.Time
+
+	// SearchResults are the regions of the file that search recipes matched
+	SearchResults []SearchResult
 }
 
 // ResultsContainer holds all the results from rewrite operations
@@ -78,8 +148,78 @@ type ResultsContainer struct {
 	Deleted           []Result
 	Moved             []Result
 	RefactoredInPlace []Result
-	ProjectRoot       string
-	FirstException    error
+	Conflicts         []Conflict
+	// Skipped holds the results that were not applied because their files changed on disk
+	// They are not counted as changes.
+	Skipped        []Result
+	SearchResults  []SearchResult
+	RecipeStats    []*RecipeStats
+	ParserStats    []*ParserStats
+	DataTables     *DataTableStore
+	ProjectRoot    string
+	FilesProcessed int
+
+	// Interrupted is set when the run was cancelled before every source file was processed
+	Interrupted bool
+
+	// Errors holds every error produced while processing a source file, in the order of the files
+	// Recipe failures are *RecipeError. Files with an error have no result.
+	Errors []error
+}
+
+// RecipeStats holds execution statistics of a single recipe across all files
+type RecipeStats struct {
+	Name           string
+	Duration       time.Duration
+	FilesVisited   int
+	FilesChanged   int
+	SearchResults  int
+	TimeSaved      time.Duration
+	AllocatedBytes uint64
+	Errors         []*RecipeError
+}
+
+// RecipeError is an error a recipe produced while visiting a source file
+type RecipeError struct {
+	// Recipe is the active recipe that failed
+	Recipe string
+	Path   string
+	Err    error
+
+	// RecipeStack is the chain of recipes from Recipe down to the recipe of its recipe list that failed
+	RecipeStack []string
+	// Stack is the stack trace of the goroutine if the recipe panicked
+	Stack string
+}
+
+// newRecipeError creates the error of an active recipe that failed on a source file
+// The recipe frames added by composite recipes are unwrapped into the recipe stack.
+func newRecipeError(recipe, path string, err error) *RecipeError {
+	recipeErr := &RecipeError{Recipe: recipe, Path: path, RecipeStack: []string{recipe}}
+
+	var panicked *recipePanic
+	if errors.As(err, &panicked) {
+		recipeErr.Stack = panicked.stack
+	}
+
+	var frame *recipeFrame
+	for errors.As(err, &frame) {
+		recipeErr.RecipeStack = append(recipeErr.RecipeStack, frame.recipe)
+		err = frame.err
+	}
+	recipeErr.Err = err
+
+	return recipeErr
+}
+
+// Error implements the error interface
+func (e *RecipeError) Error() string {
+	return fmt.Sprintf("recipe %s failed on %s: %v", strings.Join(e.RecipeStack, " > "), e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *RecipeError) Unwrap() error {
+	return e.Err
 }
 
 // NewRewriter creates a new Rewriter instance
@@ -110,10 +250,18 @@ func (r *Rewriter) LoadEnvironment() error {
 		}
 	}
 
+	env.Recipes = append([]Recipe(nil), env.ActiveRecipes...)
+	env.Styles = append([]Style(nil), env.ActiveStyles...)
+
 	// Apply active recipes filter
 	r.filterActiveRecipes(env)
 	r.filterActiveStyles(env)
 
+	env.StyleSet, err = resolveStyles(env)
+	if err != nil {
+		return fmt.Errorf("failed to resolve styles: %w", err)
+	}
+
 	r.Environment = env
 	return nil
 }
@@ -144,11 +292,102 @@ func (r *Rewriter) loadConfigurationFile(location string, env *Environment) erro
 		}
 	}
 
-	// Parse YAML configuration
+	// Parse YAML configuration, which may hold several documents separated by ---
+	// Every document is checked before decoding it, so all structural errors are reported with their position
+	var errs []ValidationIssue
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		err = decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse YAML config: %w", err)
+		}
+
+		issues := checkConfigurationDocument(location, &document)
+		env.Issues = append(env.Issues, issues...)
+		if documentErrs := validationErrors(issues); len(documentErrs) > 0 {
+			errs = append(errs, documentErrs...)
+			continue
+		}
+
+		err = loadConfigurationDocument(location, &document, env)
+		if err != nil {
+			return fmt.Errorf("failed to parse YAML config: %w", err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Issues: errs}
+	}
+	return nil
+}
+
+// loadConfigurationDocument loads the recipes, styles or category of one document of a configuration file
+func loadConfigurationDocument(location string, document *yaml.Node, env *Environment) error {
 	var rewriteConfig RewriteConfig
-	err = yaml.Unmarshal(content, &rewriteConfig)
+	err := document.Decode(&rewriteConfig)
 	if err != nil {
-		return fmt.Errorf("failed to parse YAML config: %w", err)
+		return err
+	}
+
+	if rewriteConfig.Type == CategorySpecType {
+		var category Category
+		err = document.Decode(&category)
+		if err != nil {
+			return err
+		}
+		category.position = nodePosition(location, document.Content[0])
+		env.Categories = append(env.Categories, category)
+		return nil
+	}
+
+	if rewriteConfig.Type == StyleSpecType {
+		var style Style
+		err = document.Decode(&style)
+		if err != nil {
+			return err
+		}
+		style.declared = true
+		style.position = nodePosition(location, document.Content[0])
+		env.ActiveStyles = append(env.ActiveStyles, style)
+		return nil
+	}
+
+	// A recipe document with a name declares that recipe, whose recipeList is not activated on its own
+	if rewriteConfig.Type == RecipeSpecType && rewriteConfig.Name != "" {
+		var recipe Recipe
+		err = document.Decode(&recipe)
+		if err != nil {
+			return err
+		}
+		recipe.Config = nil
+		recipe.declared = true
+		recipe.position = nodePosition(location, document.Content[0])
+		for i := range recipe.RecipeList {
+			recipe.RecipeList[i].position.File = location
+		}
+		env.ActiveRecipes = append(env.ActiveRecipes, recipe)
+		rewriteConfig.RecipeList = nil
+	}
+
+	// Remember where recipes are defined and referenced
+	recipeNodes := mappingValue(document.Content[0], "recipes")
+	for i := range rewriteConfig.Recipes {
+		recipe := &rewriteConfig.Recipes[i]
+		recipe.declared = true
+		recipe.position = nodePosition(location, recipeNodes.Content[i])
+		for j := range recipe.RecipeList {
+			recipe.RecipeList[j].position.File = location
+		}
+	}
+
+	styleNodes := mappingValue(document.Content[0], "styles")
+	for i := range rewriteConfig.Styles {
+		rewriteConfig.Styles[i].declared = true
+		rewriteConfig.Styles[i].position = nodePosition(location, styleNodes.Content[i])
 	}
 
 	// Load recipes and styles into environment
@@ -156,56 +395,81 @@ func (r *Rewriter) loadConfigurationFile(location string, env *Environment) erro
 	env.ActiveStyles = append(env.ActiveStyles, rewriteConfig.Styles...)
 
 	// Add recipes from recipeList
-	for _, recipeName := range rewriteConfig.RecipeList {
-		env.ActiveRecipes = append(env.ActiveRecipes, Recipe{Name: recipeName})
+	for _, entry := range rewriteConfig.RecipeList {
+		entry.position.File = location
+		env.ActiveRecipes = append(env.ActiveRecipes, Recipe{Name: entry.Name, Options: entry.Options, position: entry.position})
 	}
 
 	// Add styles from styleList
-	for _, styleName := range rewriteConfig.StyleList {
-		env.ActiveStyles = append(env.ActiveStyles, Style{Name: styleName})
+	styleListNodes := mappingValue(document.Content[0], "styleList")
+	for i, styleName := range rewriteConfig.StyleList {
+		env.ActiveStyles = append(env.ActiveStyles, Style{Name: styleName, position: nodePosition(location, styleListNodes.Content[i])})
 	}
 
 	return nil
 }
 
-// filterActiveRecipes filters recipes based on configuration
+// filterActiveRecipes selects the active recipes named by the configuration
+// Each entry is a recipe name, a glob such as org.openrewrite.text.* or a tag selector such as tag:security.
+// Recipes the configuration file activates keep their options, other available recipes are activated without.
 func (r *Rewriter) filterActiveRecipes(env *Environment) {
-	activeRecipeNames := r.Config.GetActiveRecipes()
-	if len(activeRecipeNames) == 0 {
+	selectors := r.Config.GetActiveRecipes()
+	if len(selectors) == 0 {
 		return
 	}
 
-	nameSet := make(map[string]bool)
-	for _, name := range activeRecipeNames {
-		nameSet[name] = true
-	}
-
+	available := availableRecipes(env)
+	selected := make(map[string]bool)
 	var filteredRecipes []Recipe
-	for _, recipe := range env.ActiveRecipes {
-		if nameSet[recipe.Name] {
-			filteredRecipes = append(filteredRecipes, recipe)
+	for _, selector := range selectors {
+		for _, name := range selectRecipes(selector, available) {
+			if selected[name] {
+				continue
+			}
+			selected[name] = true
+
+			configured := false
+			for _, recipe := range env.ActiveRecipes {
+				if recipe.Name == name {
+					filteredRecipes = append(filteredRecipes, recipe)
+					configured = true
+				}
+			}
+			if !configured {
+				filteredRecipes = append(filteredRecipes, Recipe{Name: name})
+			}
 		}
 	}
 
 	env.ActiveRecipes = filteredRecipes
 }
 
-// filterActiveStyles filters styles based on configuration
+// filterActiveStyles selects the active styles named by the configuration
+// Styles the configuration file activates keep their style configurations, other names refer to declared
+// or built-in styles, in the order they are named.
 func (r *Rewriter) filterActiveStyles(env *Environment) {
 	activeStyleNames := r.Config.GetActiveStyles()
 	if len(activeStyleNames) == 0 {
 		return
 	}
 
-	nameSet := make(map[string]bool)
+	selected := make(map[string]bool)
+	var filteredStyles []Style
 	for _, name := range activeStyleNames {
-		nameSet[name] = true
-	}
+		if selected[name] {
+			continue
+		}
+		selected[name] = true
 
-	var filteredStyles []Style
-	for _, style := range env.ActiveStyles {
-		if nameSet[style.Name] {
-			filteredStyles = append(filteredStyles, style)
+		configured := false
+		for _, style := range env.ActiveStyles {
+			if style.Name == name {
+				filteredStyles = append(filteredStyles, style)
+				configured = true
+			}
+		}
+		if !configured {
+			filteredStyles = append(filteredStyles, Style{Name: name})
 		}
 	}
 
@@ -233,16 +497,35 @@ func (r *Rewriter) GetBuildRoot() (string, error) {
 }
 
 // FindSourceFiles discovers source files to process
-func (r *Rewriter) FindSourceFiles(rootDir string) ([]string, error) {
+// Discovery stops with the cause of the cancellation when ctx is cancelled.
+func (r *Rewriter) FindSourceFiles(ctx context.Context, rootDir string) ([]string, error) {
 	var sourceFiles []string
 	exclusions := r.Config.GetExclusions()
 	plainTextMasks := r.Config.GetPlainTextMasks()
 
-	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+	generated, err := r.generatedPaths(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Progress.Start("Discovering source files", 0)
+	defer r.Progress.Finish()
+
+	err = filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := interruption(ctx); err != nil {
+			return err
+		}
 
+		// Never process the tool's own run history and reports
+		if generated[filepath.Clean(path)] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if info.IsDir() {
 			return nil
 		}
@@ -266,6 +549,7 @@ func (r *Rewriter) FindSourceFiles(rootDir string) ([]string, error) {
 		// Check if it matches plain text masks or is a known source file type
 		if r.matchesPatterns(relPath, plainTextMasks) || r.isSourceFile(relPath) {
 			sourceFiles = append(sourceFiles, path)
+			r.Progress.Add(1)
 		}
 
 		return nil
@@ -274,6 +558,40 @@ func (r *Rewriter) FindSourceFiles(rootDir string) ([]string, error) {
 	return sourceFiles, err
 }
 
+// generatedPaths returns the files and directories under rootDir that rewrite-go writes itself: the run
+// history, the report output directory with patches and data tables, and the configured reports
+// Processing them would make a run rewrite the patches and reports of earlier runs.
+func (r *Rewriter) generatedPaths(rootDir string) (map[string]bool, error) {
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	paths := []string{
+		filepath.Join(absRoot, runHistoryRoot),
+		r.Config.GetReportOutputDirectory(absRoot),
+	}
+	// Invalid report specifications are reported before discovery
+	targets, _ := parseReportTargets(r.Config.Reports)
+	for _, target := range targets {
+		paths = append(paths, target.Path)
+	}
+
+	generated := make(map[string]bool)
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		rel, err := filepath.Rel(absRoot, abs)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		generated[filepath.Join(rootDir, rel)] = true
+	}
+	return generated, nil
+}
+
 // matchesPatterns checks if a path matches any of the given patterns
 func (r *Rewriter) matchesPatterns(path string, patterns []string) bool {
 	for _, pattern := range patterns {
@@ -321,25 +639,111 @@ func (r *Rewriter) isSourceFile(path string) bool {
 }
 
 // ProcessFiles applies recipes to the discovered source files
-func (r *Rewriter) ProcessFiles(sourceFiles []string) (*ResultsContainer, error) {
+// Files are processed concurrently by Config.Parallelism workers, each reading one file at a time,
+// so only the files being processed and the changed files are held in memory. Results, search
+// results, data table rows and errors are collected in the order of sourceFiles regardless of scheduling.
+// When ctx is cancelled no new files are scheduled and the results of the files processed so far are
+// returned, marked as interrupted. The fail-fast recipe error policy stops scheduling the same way at
+// the first error.
+func (r *Rewriter) ProcessFiles(ctx context.Context, sourceFiles []string) (*ResultsContainer, error) {
 	if r.Environment == nil {
 		return nil, fmt.Errorf("environment not loaded")
 	}
+	failFast, err := r.Config.failFast()
+	if err != nil {
+		return nil, configError(err)
+	}
 
+	executionCtx := NewExecutionContext(ctx, r.Config, r.Environment.StyleSet)
 	results := &ResultsContainer{
+		DataTables:  executionCtx.DataTables,
 		ProjectRoot: r.BaseDir,
 	}
 
-	for _, filePath := range sourceFiles {
-		result, err := r.processFile(filePath)
+	execution := &recipeExecution{}
+	for _, recipe := range r.Environment.ActiveRecipes {
+		visitor, err := r.buildVisitor(recipe.Name, recipe.Options, map[string]bool{})
+		if err != nil {
+			return nil, configError(err)
+		}
+		effort, err := r.estimatedEffort(recipe.Name)
 		if err != nil {
-			if results.FirstException == nil {
-				results.FirstException = err
+			return nil, configError(err)
+		}
+		execution.visitors = append(execution.visitors, visitor)
+		execution.efforts = append(execution.efforts, effort)
+		results.RecipeStats = append(results.RecipeStats, &RecipeStats{Name: recipe.Name})
+	}
+
+	scheduling, stopScheduling := context.WithCancel(ctx)
+	defer stopScheduling()
+
+	r.Progress.Start("Parsing and running recipes", len(sourceFiles))
+	defer r.Progress.Finish()
+
+	outcomes := make([]fileOutcome, len(sourceFiles))
+	workers := make([]*recipeWorker, min(r.Config.GetParallelism(), len(sourceFiles)))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := range workers {
+		worker := &recipeWorker{
+			recipeExecution: execution,
+			stats:           make([]*RecipeStats, len(execution.visitors)),
+			parsers:         map[string]*ParserStats{},
+			allocs:          newAllocCounter(r.Config.Profile),
+		}
+		for i := range worker.stats {
+			worker.stats[i] = &RecipeStats{}
+		}
+		workers[w] = worker
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// Every file gets its own data tables, which are merged in file order below
+				worker.ctx = NewExecutionContext(ctx, r.Config, r.Environment.StyleSet)
+				result, changes, err := r.processFile(sourceFiles[i], worker)
+				outcomes[i] = fileOutcome{processed: true, result: result, changes: changes, dataTables: worker.ctx.DataTables, err: err}
+				if err != nil && failFast {
+					stopScheduling()
+				}
+				r.Progress.Add(1)
 			}
+		}()
+	}
+schedule:
+	for i := range sourceFiles {
+		select {
+		case jobs <- i:
+		case <-scheduling.Done():
+			break schedule
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, outcome := range outcomes {
+		if !outcome.processed {
+			results.Interrupted = ctx.Err() != nil
 			continue
 		}
+		results.FilesProcessed++
+		executionCtx.DataTables.merge(outcome.dataTables)
+		if outcome.err != nil {
+			var recipeErr *RecipeError
+			if errors.As(outcome.err, &recipeErr) {
+				stats := results.RecipeStats[outcome.changes.failed]
+				stats.Errors = append(stats.Errors, recipeErr)
+			}
+			results.Errors = append(results.Errors, outcome.err)
+			continue
+		}
+		results.SearchResults = append(results.SearchResults, outcome.changes.searchResults...)
+
+		if result := outcome.result; result != nil {
+			recordSourcesFileResults(executionCtx, result)
 
-		if result != nil {
 			// Categorize the result
 			if result.Before == nil {
 				results.Generated = append(results.Generated, *result)
@@ -347,26 +751,104 @@ func (r *Rewriter) ProcessFiles(sourceFiles []string) (*ResultsContainer, error)
 				results.Deleted = append(results.Deleted, *result)
 			} else if result.Before.Path != result.After.Path {
 				results.Moved = append(results.Moved, *result)
-			} else if result.Before.Content != result.After.Content {
+			} else {
 				results.RefactoredInPlace = append(results.RefactoredInPlace, *result)
 			}
 		}
 	}
 
+	parsers := map[string]*ParserStats{}
+	for _, worker := range workers {
+		for i, stats := range worker.stats {
+			total := results.RecipeStats[i]
+			total.Duration += stats.Duration
+			total.FilesVisited += stats.FilesVisited
+			total.FilesChanged += stats.FilesChanged
+			total.SearchResults += stats.SearchResults
+			total.TimeSaved += stats.TimeSaved
+			total.AllocatedBytes += stats.AllocatedBytes
+		}
+		for name, stats := range worker.parsers {
+			total := parsers[name]
+			if total == nil {
+				total = &ParserStats{Name: name}
+				parsers[name] = total
+				results.ParserStats = append(results.ParserStats, total)
+			}
+			total.Duration += stats.Duration
+			total.Files += stats.Files
+			total.Bytes += stats.Bytes
+			total.AllocatedBytes += stats.AllocatedBytes
+		}
+	}
+	sort.Slice(results.ParserStats, func(i, j int) bool {
+		return results.ParserStats[i].Name < results.ParserStats[j].Name
+	})
+
 	return results, nil
 }
 
+// recipeExecution holds the active recipes of a run
+// Visitors and efforts are indexed like the active recipes.
+type recipeExecution struct {
+	visitors []RecipeVisitor
+	efforts  []time.Duration
+}
+
+// recipeWorker processes one source file at a time
+// Its statistics are merged into the results once all files are processed.
+type recipeWorker struct {
+	*recipeExecution
+	ctx     *ExecutionContext
+	stats   []*RecipeStats
+	parsers map[string]*ParserStats
+	allocs  *allocCounter
+}
+
+// fileOutcome is the outcome of processing a single source file
+type fileOutcome struct {
+	processed  bool
+	result     *Result
+	changes    recipeChanges
+	dataTables *DataTableStore
+	err        error
+}
+
+// recipeChanges describes what the active recipes did to a source file
+type recipeChanges struct {
+	recipes         []string
+	recipeTimeSaved map[int]time.Duration
+	edits           []recipeEdit
+	searchResults   []SearchResult
+	timeSaved       time.Duration
+
+	// failed is the index of the recipe that produced an error, if any
+	failed int
+}
+
 // processFile processes a single file through the active recipes
-func (r *Rewriter) processFile(filePath string) (*Result, error) {
+// It returns the change made to the file, if any, and what the recipes did to it.
+func (r *Rewriter) processFile(filePath string, worker *recipeWorker) (*Result, recipeChanges, error) {
+	parser := worker.parsers[parserName(filePath)]
+	if parser == nil {
+		parser = &ParserStats{Name: parserName(filePath)}
+		worker.parsers[parser.Name] = parser
+	}
+	start, allocated := time.Now(), worker.allocs.read()
+
 	// Read the file
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, recipeChanges{}, fmt.Errorf("failed to stat file %s: %w", filePath, err)
+	}
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		return nil, recipeChanges{}, fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
 
 	relPath, err := filepath.Rel(r.BaseDir, filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get relative path: %w", err)
+		return nil, recipeChanges{}, fmt.Errorf("failed to get relative path: %w", err)
 	}
 
 	before := &SourceFile{
@@ -374,43 +856,197 @@ func (r *Rewriter) processFile(filePath string) (*Result, error) {
 		Content:  string(content),
 		Charset:  "UTF-8",
 		Modified: false,
+		Mode:     info.Mode().Perm(),
+		Checksum: checksum(content),
+		ModTime:  info.ModTime(),
 	}
 
-	// Apply recipes (this is a simplified placeholder)
-	// In a real implementation, this would invoke the actual OpenRewrite recipes
-	after := r.applyRecipes(before)
+	parser.Duration += time.Since(start)
+	parser.Files++
+	parser.Bytes += int64(len(content))
+	parser.AllocatedBytes += worker.allocs.read() - allocated
+
+	after, changes, err := r.applyRecipes(before, worker)
+	if err != nil {
+		return nil, changes, err
+	}
 
-	if before.Content == after.Content {
-		return nil, nil // No changes
+	if !sourceFileChanged(before, after) {
+		return nil, changes, nil // No changes
 	}
 
 	return &Result{
 		Before:                 before,
 		After:                  after,
-		RecipesThatMadeChanges: r.getActiveRecipeNames(),
-		TimeSaved:              time.Minute, // Placeholder
-	}, nil
-}
-
-// applyRecipes applies the active recipes to a source file
-// This is a simplified placeholder implementation
-func (r *Rewriter) applyRecipes(sourceFile *SourceFile) *SourceFile {
-	// This is where the actual recipe application would happen
-	// For now, this is a placeholder that doesn't modify anything
-	after := &SourceFile{
-		Path:     sourceFile.Path,
-		Content:  sourceFile.Content,
-		Charset:  sourceFile.Charset,
-		Modified: false,
+		RecipesThatMadeChanges: changes.recipes,
+		TimeSaved:              changes.timeSaved,
+		recipeTimeSaved:        changes.recipeTimeSaved,
+		edits:                  changes.edits,
+	}, changes, nil
+}
+
+// applyRecipes applies the active recipes to a source file, one after the other
+// It returns the transformed file, or nil if a recipe deleted it, and what the recipes did to it.
+// Every recipe that changes the file adds its estimated effort per occurrence of its change to the time saved.
+func (r *Rewriter) applyRecipes(sourceFile *SourceFile, worker *recipeWorker) (*SourceFile, recipeChanges, error) {
+	current := sourceFile
+	var changes recipeChanges
+
+	for i, recipe := range r.Environment.ActiveRecipes {
+		stats := worker.stats[i]
+		start, allocated := time.Now(), worker.allocs.read()
+		after, err := visitRecipe(worker.visitors[i], worker.ctx, current)
+		stats.Duration += time.Since(start)
+		stats.AllocatedBytes += worker.allocs.read() - allocated
+		stats.FilesVisited++
+
+		if err != nil {
+			changes.failed = i
+			return nil, changes, newRecipeError(recipe.Name, sourceFile.Path, err)
+		}
+
+		if sourceFileChanged(current, after) {
+			timeSaved := time.Duration(changeOccurrences(current, after)) * worker.efforts[i]
+			changes.recipes = append(changes.recipes, recipe.Name)
+			changes.edits = append(changes.edits, recipeEdit{recipe: recipe.Name, before: current, after: after})
+			if changes.recipeTimeSaved == nil {
+				changes.recipeTimeSaved = map[int]time.Duration{}
+			}
+			changes.recipeTimeSaved[i] += timeSaved
+			changes.timeSaved += timeSaved
+			stats.FilesChanged++
+			stats.TimeSaved += timeSaved
+		}
+		if after != nil && len(after.SearchResults) > len(current.SearchResults) {
+			marked := after.SearchResults[len(current.SearchResults):]
+			for j := range marked {
+				if marked[j].Recipe == "" {
+					marked[j].Recipe = recipe.Name
+				}
+			}
+			changes.searchResults = append(changes.searchResults, marked...)
+			stats.SearchResults += len(marked)
+		}
+		current = after
+
+		// Later recipes have nothing left to visit once a file is deleted
+		if current == nil {
+			break
+		}
+	}
+
+	return current, changes, nil
+}
+
+// sourceFileChanged reports whether a recipe changed, moved or deleted a source file
+func sourceFileChanged(before, after *SourceFile) bool {
+	if after == nil {
+		return true
+	}
+	return before.Content != after.Content || before.Path != after.Path || before.Mode != after.Mode
+}
+
+// changeOccurrences counts the separate changes a recipe made to a source file
+// Each run of adjacent changed lines is one occurrence. Moving, deleting or changing the mode of a file
+// without editing its content is a single occurrence.
+func changeOccurrences(before, after *SourceFile) int {
+	if after == nil || before.Content == after.Content {
+		return 1
+	}
+	return max(len(Hunks(DiffText(before.Content, after.Content), 0)), 1)
+}
+
+// hunkRecipes returns the recipes that changed the lines of each hunk of the diff of a result, in the order they ran
+// The lines every recipe changed are traced back to the lines of the file before the run. A hunk no recipe
+// is known to have changed, as when the edits of the recipes are not known, is attributed to the first recipe.
+func (r Result) hunkRecipes(hunks []Hunk) [][]string {
+	// spans are the ranges of lines of the file before the run that each recipe changed, where an empty
+	// range stands for lines inserted before its start
+	type span struct{ start, end int }
+	overlaps := func(a, b span) bool {
+		if a.start == a.end || b.start == b.end {
+			return a.start <= b.end && b.start <= a.end
+		}
+		return a.start < b.end && b.start < a.end
+	}
+	spans := map[string][]span{}
+
+	// origins are the lines of the file before the run that the lines of the edited file come from, or -1
+	lineCount := len(splitLines(r.Before.Content))
+	origins := make([]int, lineCount)
+	for i := range origins {
+		origins[i] = i
+	}
+	// anchor returns the first line of the file before the run that is kept at or after a line of the edited file
+	anchor := func(index int) int {
+		for ; index < len(origins); index++ {
+			if origins[index] >= 0 {
+				return origins[index]
+			}
+		}
+		return lineCount
 	}
 
-	// TODO: Implement actual recipe application logic
-	// This would involve:
-	// 1. Parsing the source file into an AST
-	// 2. Applying each active recipe to the AST
-	// 3. Converting the modified AST back to source code
+	for _, edit := range r.edits {
+		if edit.after == nil {
+			continue
+		}
+		var next []int
+		index := 0
+		for _, line := range DiffText(edit.before.Content, edit.after.Content) {
+			switch line.Op {
+			case DiffEqual:
+				next = append(next, origins[index])
+				index++
+			case DiffDelete:
+				if origin := origins[index]; origin >= 0 {
+					spans[edit.recipe] = append(spans[edit.recipe], span{origin, origin + 1})
+				} else {
+					spans[edit.recipe] = append(spans[edit.recipe], span{anchor(index), anchor(index)})
+				}
+				index++
+			case DiffInsert:
+				next = append(next, -1)
+				spans[edit.recipe] = append(spans[edit.recipe], span{anchor(index), anchor(index)})
+			}
+		}
+		origins = next
+	}
 
-	return after
+	recipes := make([][]string, len(hunks))
+	for i, hunk := range hunks {
+		changed := span{hunk.OldStart - 1, hunk.OldStart - 1 + hunk.OldCount}
+		if hunk.OldCount == 0 {
+			changed = span{hunk.OldStart, hunk.OldStart}
+		}
+		for _, recipe := range r.RecipesThatMadeChanges {
+			for _, s := range spans[recipe] {
+				if overlaps(s, changed) {
+					recipes[i] = append(recipes[i], recipe)
+					break
+				}
+			}
+		}
+		if len(recipes[i]) == 0 && len(r.RecipesThatMadeChanges) > 0 {
+			recipes[i] = r.RecipesThatMadeChanges[:1]
+		}
+	}
+	return recipes
+}
+
+// recordSourcesFileResults adds a changed file to the SourcesFileResults data table
+func recordSourcesFileResults(ctx *ExecutionContext, result *Result) {
+	row := SourcesFileResultsRow{EstimatedTimeSaving: result.TimeSaved.Seconds()}
+	if result.Before != nil {
+		row.SourcePath = filepath.ToSlash(result.Before.Path)
+	}
+	if result.After != nil {
+		row.AfterSourcePath = filepath.ToSlash(result.After.Path)
+	}
+	for _, recipe := range result.RecipesThatMadeChanges {
+		row.Recipe = recipe
+		sourcesFileResults.InsertRow(ctx, row)
+	}
 }
 
 // getActiveRecipeNames returns the names of active recipes
@@ -427,3 +1063,27 @@ func (rc *ResultsContainer) IsNotEmpty() bool {
 	return len(rc.Generated) > 0 || len(rc.Deleted) > 0 ||
 		len(rc.Moved) > 0 || len(rc.RefactoredInPlace) > 0
 }
+
+// ChangeCount returns the number of files that are generated, deleted, moved or refactored
+func (rc *ResultsContainer) ChangeCount() int {
+	return len(rc.Generated) + len(rc.Deleted) + len(rc.Moved) + len(rc.RefactoredInPlace)
+}
+
+// skip moves a result from the changes to Skipped and takes it out of the statistics of its recipes
+func (rc *ResultsContainer) skip(skipped Result) {
+	for _, category := range []*[]Result{&rc.Generated, &rc.Deleted, &rc.Moved, &rc.RefactoredInPlace} {
+		var kept []Result
+		for _, result := range *category {
+			if result.Before != skipped.Before || result.After != skipped.After {
+				kept = append(kept, result)
+				continue
+			}
+			rc.Skipped = append(rc.Skipped, result)
+			for i, timeSaved := range result.recipeTimeSaved {
+				rc.RecipeStats[i].FilesChanged--
+				rc.RecipeStats[i].TimeSaved -= timeSaved
+			}
+		}
+		*category = kept
+	}
+}
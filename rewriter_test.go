@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// visitedFilesRow is a row of the table the ordering test recipe fills
+type visitedFilesRow struct {
+	SourcePath string `column:"sourcePath"`
+}
+
+// visitedFiles lists every file the ordering test recipe visits
+var visitedFiles = NewDataTable[visitedFilesRow]("test.VisitedFiles", "Visited files", "Files the ordering test recipe visited.")
+
+// registerTestRecipe registers a recipe for the duration of a test
+func registerTestRecipe(t *testing.T, descriptor *RecipeDescriptor) {
+	t.Helper()
+	RegisterRecipe(descriptor)
+	t.Cleanup(func() { delete(recipeRegistry, descriptor.Name) })
+}
+
+func TestProcessFilesKeepsFileOrder(t *testing.T) {
+	// test.Ordered finishes later files first, marks and changes every file and records it in a data table
+	registerTestRecipe(t, &RecipeDescriptor{
+		Name: "test.Ordered",
+		New: func(options RecipeOptions) (RecipeVisitor, error) {
+			return RecipeVisitorFunc(func(ctx *ExecutionContext, sourceFile *SourceFile) (*SourceFile, error) {
+				index, err := strconv.Atoi(strings.TrimSpace(sourceFile.Content))
+				if err != nil {
+					return nil, err
+				}
+				time.Sleep(time.Duration(20-index) * time.Millisecond)
+
+				visitedFiles.InsertRow(ctx, visitedFilesRow{SourcePath: sourceFile.Path})
+				after := withContent(sourceFile, sourceFile.Content+"visited\n")
+				after.SearchResults = append(after.SearchResults, SearchResult{Path: sourceFile.Path, Line: 1, Column: 1})
+				return after, nil
+			}), nil
+		},
+	})
+
+	dir := t.TempDir()
+	var sourceFiles, want []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("file%02d.txt", i)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(fmt.Sprintf("%d\n", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sourceFiles = append(sourceFiles, filepath.Join(dir, name))
+		want = append(want, name)
+	}
+
+	rewriter := NewRewriter(&Config{Parallelism: 8}, dir)
+	rewriter.Environment = &Environment{ActiveRecipes: []Recipe{{Name: "test.Ordered"}}}
+	results, err := rewriter.ProcessFiles(context.Background(), sourceFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Errors) > 0 {
+		t.Fatal(results.Errors)
+	}
+
+	var changed, searched []string
+	for _, result := range results.RefactoredInPlace {
+		changed = append(changed, result.After.Path)
+	}
+	for _, result := range results.SearchResults {
+		searched = append(searched, result.Path)
+	}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("results are in order %v, want %v", changed, want)
+	}
+	if !reflect.DeepEqual(searched, want) {
+		t.Errorf("search results are in order %v, want %v", searched, want)
+	}
+
+	rows := map[string][]string{}
+	for _, table := range results.DataTables.Tables() {
+		column := 0
+		if table.Descriptor.Name == sourcesFileResults.Descriptor().Name {
+			column = 1 // afterSourcePath
+		}
+		for _, row := range table.Rows {
+			rows[table.Descriptor.Name] = append(rows[table.Descriptor.Name], row[column])
+		}
+	}
+	for _, table := range []string{visitedFiles.Descriptor().Name, sourcesFileResults.Descriptor().Name} {
+		if !reflect.DeepEqual(rows[table], want) {
+			t.Errorf("rows of %s are in order %v, want %v", table, rows[table], want)
+		}
+	}
+}
+
+func TestResultHunkRecipes(t *testing.T) {
+	file := func(content string) *SourceFile { return &SourceFile{Path: "a.txt", Content: content} }
+	tests := []struct {
+		name  string
+		steps []string
+		want  [][]string
+	}{
+		{
+			name:  "separate lines",
+			steps: []string{"1\n2\n3\n4\n5\n", "one\n2\n3\n4\n5\n", "one\n2\n3\n4\nfive\n"},
+			want:  [][]string{{"A"}, {"B"}},
+		},
+		{
+			name:  "insertion after an earlier change",
+			steps: []string{"1\n2\n3\n4\n5\n", "1\n2\n3\n", "0\n1\n2\n3\n"},
+			want:  [][]string{{"B"}, {"A"}},
+		},
+		{
+			name:  "same line",
+			steps: []string{"1\n2\n3\n", "1\ntwo\n3\n", "1\nTWO\n3\n"},
+			want:  [][]string{{"A", "B"}},
+		},
+		{
+			name:  "line inserted by an earlier recipe",
+			steps: []string{"1\n2\n3\n4\n5\n", "1\n2\n3\n4\n5\n6\n", "1\n2\n3\n4\n5\nsix\n"},
+			want:  [][]string{{"A", "B"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Result{Before: file(tt.steps[0]), After: file(tt.steps[len(tt.steps)-1])}
+			for i := 1; i < len(tt.steps); i++ {
+				recipe := string(rune('A' + i - 1))
+				result.RecipesThatMadeChanges = append(result.RecipesThatMadeChanges, recipe)
+				result.edits = append(result.edits, recipeEdit{recipe: recipe, before: file(tt.steps[i-1]), after: file(tt.steps[i])})
+			}
+
+			hunks := Hunks(DiffText(result.Before.Content, result.After.Content), 0)
+			if got := result.hunkRecipes(hunks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hunkRecipes() = %v, want %v", got, tt.want)
+			}
+
+			result.edits = nil
+			for i, recipes := range result.hunkRecipes(hunks) {
+				if !reflect.DeepEqual(recipes, []string{"A"}) {
+					t.Errorf("hunkRecipes() without edits attributes hunk %d to %v, want [A]", i, recipes)
+				}
+			}
+		})
+	}
+}
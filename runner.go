@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,29 +16,59 @@ import (
 // This mirrors the AbstractRewriteRunMojo functionality
 type Runner struct {
 	Rewriter *Rewriter
-	Logger   *log.Logger
+	Logger   *slog.Logger
+
+	// DiffOutput receives the unified diff of a dry run, if set
+	DiffOutput io.Writer
 }
 
 // NewRunner creates a new Runner instance
+// The rewriter reports its progress through the runner's logger unless it already has a progress reporter.
+// While a progress bar is shown, the runner's log records clear it before they are written.
 func NewRunner(rewriter *Rewriter) *Runner {
-	return &Runner{
+	runner := &Runner{
 		Rewriter: rewriter,
-		Logger:   log.New(os.Stdout, "[REWRITE] ", log.LstdFlags),
+		Logger:   newRunLogger(rewriter.Config),
+	}
+	if rewriter.Progress == nil && rewriter.Config != nil {
+		rewriter.Progress, _ = NewProgress(runner.Logger, rewriter.Config)
+	}
+	runner.Logger = rewriter.Progress.wrapLogger(runner.Logger)
+	return runner
+}
+
+// processFiles runs the active recipes on the source files
+func (r *Runner) processFiles(ctx context.Context, sourceFiles []string) (*ResultsContainer, error) {
+	if r.Rewriter.Config.Profile && r.Rewriter.Config.Parallelism != 1 {
+		r.Logger.Info("Processing files one at a time to attribute allocations to recipes, as --profile is set")
 	}
+	return r.Rewriter.ProcessFiles(ctx, sourceFiles)
 }
 
 // Execute runs the rewrite operation
 // This mirrors the execute() method from AbstractRewriteRunMojo
-func (r *Runner) Execute() error {
+// When ctx is cancelled the run stops early; changes are only applied if every file was processed.
+func (r *Runner) Execute(ctx context.Context) error {
 	if r.Rewriter.Config.Skip {
-		r.Logger.Println("Skipping execution")
+		r.Logger.Info("Skipping execution")
 		return nil
 	}
+	startedAt := time.Now()
+
+	// Validate the requested reports before doing any work
+	_, err := parseReportTargets(r.Rewriter.Config.Reports)
+	if err != nil {
+		return configError(err)
+	}
 
 	// Load the environment
-	err := r.Rewriter.LoadEnvironment()
+	err = r.Rewriter.LoadEnvironment()
+	if err != nil {
+		return configError(fmt.Errorf("failed to load environment: %w", err))
+	}
+	err = r.validateEnvironment()
 	if err != nil {
-		return fmt.Errorf("failed to load environment: %w", err)
+		return err
 	}
 
 	// Get the build root
@@ -44,108 +77,165 @@ func (r *Runner) Execute() error {
 		return fmt.Errorf("failed to get build root: %w", err)
 	}
 
-	r.Logger.Printf("Processing project at: %s", buildRoot)
+	r.Logger.Info("Processing project", "root", buildRoot)
+	err = r.recoverInterruptedRun(buildRoot)
+	if err != nil {
+		return err
+	}
 
 	// Find source files
-	sourceFiles, err := r.Rewriter.FindSourceFiles(buildRoot)
+	sourceFiles, err := r.Rewriter.FindSourceFiles(ctx, buildRoot)
 	if err != nil {
 		return fmt.Errorf("failed to find source files: %w", err)
 	}
 
-	r.Logger.Printf("Found %d source files to process", len(sourceFiles))
+	r.Logger.Info("Found source files to process", "count", len(sourceFiles))
 
 	if len(sourceFiles) == 0 {
-		r.Logger.Println("No source files found to process")
+		r.Logger.Info("No source files found to process")
 		return nil
 	}
 
 	// Process the files
-	results, err := r.Rewriter.ProcessFiles(sourceFiles)
+	results, err := r.processFiles(ctx, sourceFiles)
 	if err != nil {
 		return fmt.Errorf("failed to process files: %w", err)
 	}
+	if results.Interrupted {
+		return r.reportInterrupted(ctx, "run", startedAt, len(sourceFiles), results)
+	}
 
-	// Handle first exception if any
-	if results.FirstException != nil {
-		r.Logger.Printf("ERROR: The recipe produced an error: %v", results.FirstException)
-		return results.FirstException
+	// Handle recipe errors, only the apply-successful policy carries on with the files without errors
+	if len(results.Errors) > 0 {
+		r.logRecipeErrors(results, len(sourceFiles))
+		if !r.Rewriter.Config.ApplySuccessfulChanges() {
+			if err := r.writeReports("run", startedAt, results); err != nil {
+				r.Logger.Warn("Failed to write reports", "error", err)
+			}
+			return recipeErrors(results.Errors)
+		}
+		r.Logger.Warn("Continuing with the changes of the files without errors")
+	}
+
+	err = r.exportDataTables(buildRoot, results)
+	if err != nil {
+		return err
 	}
 
 	// Report results
 	if results.IsNotEmpty() {
-		err = r.reportAndApplyResults(results)
+		err = r.reportAndApplyResults(ctx, results)
 		if err != nil {
+			if interruption(ctx) != nil {
+				if reportErr := r.writeReports("run", startedAt, results); reportErr != nil {
+					r.Logger.Warn("Failed to write reports", "error", reportErr)
+				}
+			}
 			return fmt.Errorf("failed to apply results: %w", err)
 		}
 	} else {
-		r.Logger.Println("No changes were made")
+		r.Logger.Info("No changes were made")
+	}
+
+	// Search results never change files, so they are only counted
+	if len(results.SearchResults) > 0 {
+		r.Logger.Info("Found search results, use 'rewrite-go search' or dry-run to list them", "count", len(results.SearchResults))
+	}
+
+	if r.Rewriter.Config.Profile {
+		r.logProfile(results)
+	}
+
+	err = r.writeReports("run", startedAt, results)
+	if err != nil {
+		return err
 	}
 
+	if len(results.Errors) > 0 {
+		return recipeErrors(results.Errors)
+	}
 	return nil
 }
 
-// reportAndApplyResults reports the results and applies the changes
+// reportAndApplyResults applies the changes and reports the results
 // This mirrors the result processing logic from AbstractRewriteRunMojo
-func (r *Runner) reportAndApplyResults(results *ResultsContainer) error {
-	var totalTimeSaved time.Duration
+// The changes are reported once applied, so results skipped because of a conflict are left out.
+func (r *Runner) reportAndApplyResults(ctx context.Context, results *ResultsContainer) error {
+	// Apply the changes
+	err := r.applyChanges(ctx, results)
+	if err != nil {
+		return fmt.Errorf("failed to apply changes: %w", err)
+	}
+	if !results.IsNotEmpty() {
+		r.Logger.Info("No changes were made")
+		return nil
+	}
 
 	// Report generated files
 	for _, result := range results.Generated {
 		if result.After != nil {
-			r.Logger.Printf("Generated new file %s by:", result.After.Path)
-			r.logRecipesThatMadeChanges(result.RecipesThatMadeChanges)
-			totalTimeSaved += result.TimeSaved
+			r.Logger.Info("Generated new file", "path", result.After.Path, "recipes", result.RecipesThatMadeChanges)
 		}
 	}
 
 	// Report deleted files
 	for _, result := range results.Deleted {
 		if result.Before != nil {
-			r.Logger.Printf("Deleted file %s by:", result.Before.Path)
-			r.logRecipesThatMadeChanges(result.RecipesThatMadeChanges)
-			totalTimeSaved += result.TimeSaved
+			r.Logger.Info("Deleted file", "path", result.Before.Path, "recipes", result.RecipesThatMadeChanges)
 		}
 	}
 
 	// Report moved files
 	for _, result := range results.Moved {
 		if result.Before != nil && result.After != nil {
-			r.Logger.Printf("File has been moved from %s to %s by:", result.Before.Path, result.After.Path)
-			r.logRecipesThatMadeChanges(result.RecipesThatMadeChanges)
-			totalTimeSaved += result.TimeSaved
+			r.Logger.Info("File has been moved", "from", result.Before.Path, "to", result.After.Path, "recipes", result.RecipesThatMadeChanges)
 		}
 	}
 
 	// Report refactored files
 	for _, result := range results.RefactoredInPlace {
 		if result.Before != nil {
-			r.Logger.Printf("Changes have been made to %s by:", result.Before.Path)
-			r.logRecipesThatMadeChanges(result.RecipesThatMadeChanges)
-			totalTimeSaved += result.TimeSaved
+			r.Logger.Info("Changes have been made", "path", result.Before.Path, "recipes", result.RecipesThatMadeChanges)
 		}
 	}
 
-	r.Logger.Println("Please review and commit the results.")
-	r.Logger.Printf("Estimate time saved: %s", r.formatDuration(totalTimeSaved))
-
-	// Apply the changes
-	err := r.applyChanges(results)
-	if err != nil {
-		return fmt.Errorf("failed to apply changes: %w", err)
-	}
+	r.Logger.Info("Please review and commit the results.")
+	r.logTimeSaved(results)
 
 	return nil
 }
 
-// logRecipesThatMadeChanges logs the recipes that made changes
-func (r *Runner) logRecipesThatMadeChanges(recipeNames []string) {
-	for _, recipeName := range recipeNames {
-		r.Logger.Printf("  %s", recipeName)
+// logRecipeErrors logs every error produced while processing source files
+// The stack traces of recipes that panicked are only logged at debug level.
+func (r *Runner) logRecipeErrors(results *ResultsContainer, total int) {
+	r.Logger.Error("Recipes failed on source files", "count", len(results.Errors))
+
+	debug := r.Logger.Enabled(context.Background(), slog.LevelDebug)
+	panicked := false
+	for _, err := range results.Errors {
+		var recipeErr *RecipeError
+		if !errors.As(err, &recipeErr) {
+			r.Logger.Error("Failed to process source file", "error", err)
+			continue
+		}
+		r.Logger.Error("Recipe failed", "path", recipeErr.Path, "recipe", strings.Join(recipeErr.RecipeStack, " > "), "error", recipeErr.Err)
+
+		if recipeErr.Stack != "" {
+			panicked = true
+			r.Logger.Debug("Recipe panicked", "path", recipeErr.Path, "recipe", recipeErr.Recipe, "stack", recipeErr.Stack)
+		}
+	}
+	if panicked && !debug {
+		r.Logger.Info("Run with --verbose to see the stack traces of recipes that panicked")
+	}
+
+	if !results.Interrupted && results.FilesProcessed < total {
+		r.Logger.Warn("Stopped at the first error", "processed", results.FilesProcessed, "total", total)
 	}
 }
 
 // formatDuration formats a duration in a human-readable format
-func (r *Runner) formatDuration(d time.Duration) string {
+func formatDuration(d time.Duration) string {
 	if d < time.Second {
 		return "< 1 second"
 	}
@@ -158,15 +248,122 @@ func (r *Runner) formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%.1f hours", d.Hours())
 }
 
+// recoverInterruptedRun rolls back the changes of a run that was interrupted while applying them, or
+// finishes them if the run was interrupted while committing, so the tree is never left half changed
+func (r *Runner) recoverInterruptedRun(buildRoot string) error {
+	found, committed, err := RecoverTransaction(transactionJournal(buildRoot))
+	if err != nil {
+		return fmt.Errorf("failed to recover the changes of an interrupted run: %w", err)
+	}
+	switch {
+	case found && committed:
+		r.Logger.Warn("Completed the changes of a run that was interrupted while committing them")
+	case found:
+		r.Logger.Warn("Rolled back the changes of a run that was interrupted while applying them")
+		if err := removeRolledBackRun(buildRoot); err != nil {
+			r.Logger.Warn("Failed to remove the record of the rolled back run", "error", err)
+		}
+	}
+	return nil
+}
+
 // applyChanges applies all the changes to the file system
 // This mirrors the file writing logic from AbstractRewriteRunMojo
-func (r *Runner) applyChanges(results *ResultsContainer) error {
+// All changes are applied in a single transaction: if any of them fails, the
+// changes that were already made are rolled back and the tree is left untouched.
+// Cancelling ctx rolls back the transaction the same way.
+func (r *Runner) applyChanges(ctx context.Context, results *ResultsContainer) error {
 	buildRoot := results.ProjectRoot
+	tx := NewTransaction(transactionJournal(buildRoot))
+
+	// Persist the before-state before changing anything, so every applied change can be undone
+	record := NewRunRecord(r.Rewriter.getActiveRecipeNames())
+	record.AddResults(results)
+	record.Incomplete = true
+	err := record.Save(buildRoot)
+	if err != nil {
+		return fmt.Errorf("failed to save run %s, no changes have been made: %w", record.ID, err)
+	}
+
+	r.Rewriter.Progress.Start("Applying changes", results.ChangeCount())
+	err = r.stageChanges(ctx, tx, buildRoot, results)
+	r.Rewriter.Progress.Finish()
+	if err == nil {
+		err = r.commitTransaction(tx)
+	}
+	if err != nil {
+		// The record is kept if the rollback fails, as changes may remain applied
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		r.Logger.Info("All changes have been rolled back")
+		r.deleteRunRecord(buildRoot, record)
+		return err
+	}
+
+	r.reportConflicts(results)
+
+	if results.IsNotEmpty() {
+		err = record.Finalize(buildRoot, results)
+		if err != nil {
+			r.Logger.Warn("Failed to finalize run, it is kept as incomplete", "run", record.ID, "error", err)
+		}
+		r.Logger.Info("Run can be undone with: rewrite-go undo "+record.ID, "run", record.ID)
+	} else {
+		r.deleteRunRecord(buildRoot, record)
+	}
+
+	// Clean up empty directories
+	err = r.cleanupEmptyDirectories(buildRoot, results)
+	if err != nil {
+		r.Logger.Warn("Failed to cleanup empty directories", "error", err)
+	}
 
+	return nil
+}
+
+// deleteRunRecord removes the record of a run none of whose changes were applied
+func (r *Runner) deleteRunRecord(buildRoot string, record *RunRecord) {
+	err := record.Delete(buildRoot)
+	if err != nil {
+		r.Logger.Warn("Failed to remove record of a run without changes", "run", record.ID, "error", err)
+	}
+}
+
+// commitTransaction commits tx
+// Failing to clean up after the commit only warns, as the changes are permanent by then. Any other
+// error means nothing was committed, and the caller must roll the transaction back.
+func (r *Runner) commitTransaction(tx *Transaction) error {
+	err := tx.Commit()
+	var cleanupErr *CommitCleanupError
+	if errors.As(err, &cleanupErr) {
+		r.Logger.Warn("Failed to remove backup files", "error", cleanupErr.Err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return nil
+}
+
+// stageChanges performs every change of the results within the given transaction
+// Staging stops before the next change
+// once ctx is cancelled, so the write in flight is always completed before rolling back.
+func (r *Runner) stageChanges(ctx context.Context, tx *Transaction, buildRoot string, results *ResultsContainer) error {
 	// Handle generated files
 	for _, result := range results.Generated {
+		if err := interruption(ctx); err != nil {
+			return err
+		}
+		r.Rewriter.Progress.Add(1)
+		if skip, err := r.checkConflict(buildRoot, results, result); err != nil {
+			return err
+		} else if skip {
+			continue
+		}
 		if result.After != nil {
-			err := r.writeFile(buildRoot, result.After)
+			filePath := filepath.Join(buildRoot, result.After.Path)
+			err := tx.WriteFile(filePath, []byte(result.After.Content), result.After.Mode)
 			if err != nil {
 				return fmt.Errorf("failed to write generated file %s: %w", result.After.Path, err)
 			}
@@ -175,10 +372,19 @@ func (r *Runner) applyChanges(results *ResultsContainer) error {
 
 	// Handle deleted files
 	for _, result := range results.Deleted {
+		if err := interruption(ctx); err != nil {
+			return err
+		}
+		r.Rewriter.Progress.Add(1)
+		if skip, err := r.checkConflict(buildRoot, results, result); err != nil {
+			return err
+		} else if skip {
+			continue
+		}
 		if result.Before != nil {
 			filePath := filepath.Join(buildRoot, result.Before.Path)
-			err := os.Remove(filePath)
-			if err != nil && !os.IsNotExist(err) {
+			err := tx.Remove(filePath)
+			if err != nil {
 				return fmt.Errorf("failed to delete file %s: %w", filePath, err)
 			}
 		}
@@ -186,70 +392,82 @@ func (r *Runner) applyChanges(results *ResultsContainer) error {
 
 	// Handle moved files
 	for _, result := range results.Moved {
+		if err := interruption(ctx); err != nil {
+			return err
+		}
+		r.Rewriter.Progress.Add(1)
+		if skip, err := r.checkConflict(buildRoot, results, result); err != nil {
+			return err
+		} else if skip {
+			continue
+		}
 		if result.Before != nil && result.After != nil {
 			oldPath := filepath.Join(buildRoot, result.Before.Path)
 			newPath := filepath.Join(buildRoot, result.After.Path)
-
-			// Create target directory if it doesn't exist
-			targetDir := filepath.Dir(newPath)
-			err := os.MkdirAll(targetDir, 0755)
+			err := tx.Move(oldPath, newPath, []byte(result.After.Content), result.After.Mode)
 			if err != nil {
-				return fmt.Errorf("failed to create directory %s: %w", targetDir, err)
-			}
-
-			// Move/rename the file
-			err = os.Rename(oldPath, newPath)
-			if err != nil {
-				// If rename fails, copy and delete
-				err = r.writeFile(buildRoot, result.After)
-				if err != nil {
-					return fmt.Errorf("failed to write moved file %s: %w", result.After.Path, err)
-				}
-				err = os.Remove(oldPath)
-				if err != nil && !os.IsNotExist(err) {
-					return fmt.Errorf("failed to remove old file %s: %w", oldPath, err)
-				}
+				return fmt.Errorf("failed to move file %s to %s: %w", result.Before.Path, result.After.Path, err)
 			}
 		}
 	}
 
 	// Handle refactored files
 	for _, result := range results.RefactoredInPlace {
+		if err := interruption(ctx); err != nil {
+			return err
+		}
+		r.Rewriter.Progress.Add(1)
+		if skip, err := r.checkConflict(buildRoot, results, result); err != nil {
+			return err
+		} else if skip {
+			continue
+		}
 		if result.After != nil {
-			err := r.writeFile(buildRoot, result.After)
+			filePath := filepath.Join(buildRoot, result.After.Path)
+			err := tx.WriteFile(filePath, []byte(result.After.Content), result.After.Mode)
 			if err != nil {
 				return fmt.Errorf("failed to write refactored file %s: %w", result.After.Path, err)
 			}
 		}
 	}
 
-	// Clean up empty directories
-	err := r.cleanupEmptyDirectories(buildRoot, results)
-	if err != nil {
-		r.Logger.Printf("Warning: failed to cleanup empty directories: %v", err)
-	}
-
 	return nil
 }
 
-// writeFile writes a source file to disk
-func (r *Runner) writeFile(buildRoot string, sourceFile *SourceFile) error {
-	filePath := filepath.Join(buildRoot, sourceFile.Path)
-
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(filePath)
-	err := os.MkdirAll(dir, 0755)
-	if err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+// checkConflict verifies that the files touched by result were not modified since they were read
+// Depending on the conflict policy a conflict either aborts the run or skips the result, which is then
+// no longer counted as a change.
+func (r *Runner) checkConflict(buildRoot string, results *ResultsContainer, result Result) (bool, error) {
+	conflict := detectConflict(buildRoot, result)
+	if conflict == nil {
+		return false, nil
+	}
+
+	switch r.Rewriter.Config.ConflictPolicy {
+	case ConflictPolicySkip:
+		r.Logger.Warn("Skipping file modified by another process", "path", conflict.Path, "reason", conflict.Reason)
+		results.Conflicts = append(results.Conflicts, *conflict)
+		results.skip(result)
+		return true, nil
+	case ConflictPolicyFail, "":
+		results.Conflicts = append(results.Conflicts, *conflict)
+		return false, fmt.Errorf("concurrent modification detected: %w", conflict)
+	default:
+		return false, fmt.Errorf("unknown conflict policy %q", r.Rewriter.Config.ConflictPolicy)
 	}
+}
 
-	// Write the file
-	err = os.WriteFile(filePath, []byte(sourceFile.Content), 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+// reportConflicts reports the files that were skipped because they changed on disk
+func (r *Runner) reportConflicts(results *ResultsContainer) {
+	if len(results.Conflicts) == 0 {
+		return
 	}
 
-	return nil
+	r.Logger.Warn("Files were modified by another process and have not been changed", "count", len(results.Conflicts))
+	for _, conflict := range results.Conflicts {
+		r.Logger.Warn("File modified by another process", "path", conflict.Path, "reason", conflict.Reason)
+	}
+	r.Logger.Warn("Run again to apply recipes to the current contents of these files.")
 }
 
 // cleanupEmptyDirectories removes directories that have become empty
@@ -305,10 +523,10 @@ func (r *Runner) cleanupEmptyDirectories(buildRoot string, results *ResultsConta
 	}
 
 	if len(removedDirs) > 0 {
-		r.Logger.Printf("Removed %d empty directories:", len(removedDirs))
+		r.Logger.Info("Removed empty directories", "count", len(removedDirs))
 		for _, dir := range removedDirs {
 			relDir, _ := filepath.Rel(buildRoot, dir)
-			r.Logger.Printf("  %s", relDir)
+			r.Logger.Debug("Removed empty directory", "path", relDir)
 		}
 	}
 
@@ -325,16 +543,27 @@ func (r *Runner) isEmptyDir(dir string) bool {
 }
 
 // DryRun performs a dry run without making changes
-func (r *Runner) DryRun() error {
+func (r *Runner) DryRun(ctx context.Context) error {
 	if r.Rewriter.Config.Skip {
-		r.Logger.Println("Skipping dry run execution")
+		r.Logger.Info("Skipping dry run execution")
 		return nil
 	}
+	startedAt := time.Now()
+
+	// Validate the requested reports before doing any work
+	_, err := parseReportTargets(r.Rewriter.Config.Reports)
+	if err != nil {
+		return configError(err)
+	}
 
 	// Load the environment
-	err := r.Rewriter.LoadEnvironment()
+	err = r.Rewriter.LoadEnvironment()
+	if err != nil {
+		return configError(fmt.Errorf("failed to load environment: %w", err))
+	}
+	err = r.validateEnvironment()
 	if err != nil {
-		return fmt.Errorf("failed to load environment: %w", err)
+		return err
 	}
 
 	// Get the build root
@@ -343,38 +572,89 @@ func (r *Runner) DryRun() error {
 		return fmt.Errorf("failed to get build root: %w", err)
 	}
 
-	r.Logger.Printf("Dry run - processing project at: %s", buildRoot)
+	r.Logger.Info("Dry run - processing project", "root", buildRoot)
+	err = r.recoverInterruptedRun(buildRoot)
+	if err != nil {
+		return err
+	}
 
 	// Find source files
-	sourceFiles, err := r.Rewriter.FindSourceFiles(buildRoot)
+	sourceFiles, err := r.Rewriter.FindSourceFiles(ctx, buildRoot)
 	if err != nil {
 		return fmt.Errorf("failed to find source files: %w", err)
 	}
 
-	r.Logger.Printf("Found %d source files to process", len(sourceFiles))
+	r.Logger.Info("Found source files to process", "count", len(sourceFiles))
 
 	if len(sourceFiles) == 0 {
-		r.Logger.Println("No source files found to process")
+		r.Logger.Info("No source files found to process")
 		return nil
 	}
 
 	// Process the files (but don't apply changes)
-	results, err := r.Rewriter.ProcessFiles(sourceFiles)
+	results, err := r.processFiles(ctx, sourceFiles)
 	if err != nil {
 		return fmt.Errorf("failed to process files: %w", err)
 	}
+	if results.Interrupted {
+		return r.reportInterrupted(ctx, "dry-run", startedAt, len(sourceFiles), results)
+	}
+
+	// Handle recipe errors, only the apply-successful policy carries on with the files without errors
+	if len(results.Errors) > 0 {
+		r.logRecipeErrors(results, len(sourceFiles))
+		if !r.Rewriter.Config.ApplySuccessfulChanges() {
+			if err := r.writeReports("dry-run", startedAt, results); err != nil {
+				r.Logger.Warn("Failed to write reports", "error", err)
+			}
+			return recipeErrors(results.Errors)
+		}
+		r.Logger.Warn("Continuing with the changes of the files without errors")
+	}
 
-	// Handle first exception if any
-	if results.FirstException != nil {
-		r.Logger.Printf("ERROR: The recipe produced an error: %v", results.FirstException)
-		return results.FirstException
+	err = r.exportDataTables(buildRoot, results)
+	if err != nil {
+		return err
 	}
 
 	// Report what would be changed (but don't apply)
 	if results.IsNotEmpty() {
 		r.reportDryRunResults(results)
+
+		patchFile, err := r.writePatchFile(ctx, buildRoot, results)
+		if err != nil {
+			return fmt.Errorf("unable to generate rewrite result: %w", err)
+		}
+		r.Logger.Info("Patch file available", "path", patchFile)
+
+		if r.DiffOutput != nil {
+			err = WritePatch(r.DiffOutput, results)
+			if err != nil {
+				return fmt.Errorf("failed to print diff: %w", err)
+			}
+		}
+
+		r.Logger.Info("Run without --dry-run to apply these changes.")
 	} else {
-		r.Logger.Println("No changes would be made")
+		r.Logger.Info("No changes would be made")
+	}
+	r.logSearchResults(results)
+
+	if r.Rewriter.Config.Profile {
+		r.logProfile(results)
+	}
+
+	err = r.writeReports("dry-run", startedAt, results)
+	if err != nil {
+		return err
+	}
+
+	if len(results.Errors) > 0 {
+		return recipeErrors(results.Errors)
+	}
+
+	if results.IsNotEmpty() && r.Rewriter.Config.FailOnDryRunResults {
+		return ErrChangesFound
 	}
 
 	return nil
@@ -382,43 +662,43 @@ func (r *Runner) DryRun() error {
 
 // reportDryRunResults reports what would be changed in a dry run
 func (r *Runner) reportDryRunResults(results *ResultsContainer) {
-	r.Logger.Println("The following changes would be made:")
+	r.Logger.Info("The following changes would be made:")
 
 	if len(results.Generated) > 0 {
-		r.Logger.Printf("Would generate %d new files:", len(results.Generated))
+		r.Logger.Info("Would generate new files", "count", len(results.Generated))
 		for _, result := range results.Generated {
 			if result.After != nil {
-				r.Logger.Printf("  + %s", result.After.Path)
+				r.Logger.Info("Would generate file", "path", result.After.Path)
 			}
 		}
 	}
 
 	if len(results.Deleted) > 0 {
-		r.Logger.Printf("Would delete %d files:", len(results.Deleted))
+		r.Logger.Info("Would delete files", "count", len(results.Deleted))
 		for _, result := range results.Deleted {
 			if result.Before != nil {
-				r.Logger.Printf("  - %s", result.Before.Path)
+				r.Logger.Info("Would delete file", "path", result.Before.Path)
 			}
 		}
 	}
 
 	if len(results.Moved) > 0 {
-		r.Logger.Printf("Would move %d files:", len(results.Moved))
+		r.Logger.Info("Would move files", "count", len(results.Moved))
 		for _, result := range results.Moved {
 			if result.Before != nil && result.After != nil {
-				r.Logger.Printf("  %s -> %s", result.Before.Path, result.After.Path)
+				r.Logger.Info("Would move file", "from", result.Before.Path, "to", result.After.Path)
 			}
 		}
 	}
 
 	if len(results.RefactoredInPlace) > 0 {
-		r.Logger.Printf("Would modify %d files:", len(results.RefactoredInPlace))
+		r.Logger.Info("Would modify files", "count", len(results.RefactoredInPlace))
 		for _, result := range results.RefactoredInPlace {
 			if result.Before != nil {
-				r.Logger.Printf("  ~ %s", result.Before.Path)
+				r.Logger.Info("Would modify file", "path", result.Before.Path)
 			}
 		}
 	}
 
-	r.Logger.Println("Run without --dry-run to apply these changes.")
+	r.logTimeSaved(results)
 }
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// SARIF 2.1.0 log model, limited to the properties rewrite-go produces
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string         `json:"id"`
+	Name             string         `json:"name,omitempty"`
+	ShortDescription *sarifMessage  `json:"shortDescription,omitempty"`
+	FullDescription  *sarifMessage  `json:"fullDescription,omitempty"`
+	Properties       map[string]any `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Fixes      []sarifFix      `json:"fixes,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion   `json:"deletedRegion"`
+	InsertedContent *sarifMessage `json:"insertedContent,omitempty"`
+}
+
+// sarifSourceRoot is the base id every artifact location is relative to
+const sarifSourceRoot = "%SRCROOT%"
+
+// sarifRules builds the rules of a SARIF run, one per recipe
+type sarifRules struct {
+	rules   []sarifRule
+	indexes map[string]int
+	env     *Environment
+}
+
+// index returns the index of the rule for the named recipe, adding the rule if needed
+func (sr *sarifRules) index(name string) int {
+	if i, ok := sr.indexes[name]; ok {
+		return i
+	}
+
+	recipe := describeRecipe(sr.env, name)
+
+	rule := sarifRule{ID: recipe.Name, Name: recipeSimpleName(recipe.Name)}
+	displayName := recipe.DisplayName
+	if displayName == "" {
+		displayName = recipe.Name
+	}
+	rule.ShortDescription = &sarifMessage{Text: displayName}
+	if recipe.Description != "" {
+		rule.FullDescription = &sarifMessage{Text: recipe.Description}
+	}
+	if len(recipe.Tags) > 0 {
+		rule.Properties = map[string]any{"tags": recipe.Tags}
+	}
+
+	sr.indexes[name] = len(sr.rules)
+	sr.rules = append(sr.rules, rule)
+	return sr.indexes[name]
+}
+
+// recipeSimpleName returns the last segment of a fully qualified recipe name
+func recipeSimpleName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// writeSARIFReport writes the changes of a run as a SARIF 2.1.0 log
+// Every recipe becomes a rule and every change region of a file refactored in place
+// becomes a result whose fix holds the suggested replacement.
+func writeSARIFReport(w io.Writer, report *RunReport) error {
+	rules := &sarifRules{indexes: map[string]int{}, env: report.Environment}
+	if report.Environment != nil {
+		for _, recipe := range report.Environment.ActiveRecipes {
+			rules.index(recipe.Name)
+		}
+	}
+
+	results := []sarifResult{}
+	for _, result := range report.Container.RefactoredInPlace {
+		if len(result.RecipesThatMadeChanges) == 0 {
+			continue
+		}
+		results = append(results, sarifResultsOf(result, rules)...)
+	}
+	for _, searchResult := range report.Container.SearchResults {
+		results = append(results, sarifSearchResultOf(searchResult, rules))
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           report.Tool,
+			Version:        report.Version,
+			InformationURI: "https://docs.openrewrite.org/",
+			Rules:          rules.rules,
+		}},
+		Results: results,
+	}
+	if report.ProjectRoot != "" {
+		root := filepath.ToSlash(report.ProjectRoot)
+		if !strings.HasSuffix(root, "/") {
+			root += "/"
+		}
+		if !strings.HasPrefix(root, "/") {
+			root = "/" + root
+		}
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{
+			sarifSourceRoot: {URI: (&url.URL{Scheme: "file", Path: root}).String()},
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifResultsOf returns one SARIF result per change region of a result
+// Every region is a result of the rule of the recipe that changed it. When several recipes changed the same
+// region, the first of them is the rule and all are listed in the recipes property.
+func sarifResultsOf(result Result, rules *sarifRules) []sarifResult {
+	artifact := sarifArtifactLocation{
+		URI:       (&url.URL{Path: filepath.ToSlash(result.Before.Path)}).String(),
+		URIBaseID: sarifSourceRoot,
+	}
+
+	var results []sarifResult
+	hunks := Hunks(DiffText(result.Before.Content, result.After.Content), 0)
+	hunkRecipes := result.hunkRecipes(hunks)
+	for i, hunk := range hunks {
+		recipe := hunkRecipes[i][0]
+		ruleIndex := rules.index(recipe)
+		var inserted strings.Builder
+		for _, line := range hunk.Lines {
+			if line.Op == DiffInsert {
+				inserted.WriteString(line.Text)
+			}
+		}
+
+		// The deleted region spans whole lines, including their line terminators
+		deleted := sarifRegion{StartLine: hunk.OldStart, StartColumn: 1, EndLine: hunk.OldStart + hunk.OldCount, EndColumn: 1}
+		location := sarifRegion{StartLine: hunk.OldStart, EndLine: hunk.OldStart + hunk.OldCount - 1}
+		message := fmt.Sprintf("%s would change line %d", rules.rules[ruleIndex].ShortDescription.Text, hunk.OldStart)
+		if hunk.OldCount == 0 {
+			// Pure insertions happen after line OldStart
+			deleted = sarifRegion{StartLine: hunk.OldStart + 1, StartColumn: 1, EndLine: hunk.OldStart + 1, EndColumn: 1}
+			location = sarifRegion{StartLine: max(hunk.OldStart, 1)}
+			message = fmt.Sprintf("%s would insert %d lines after line %d", rules.rules[ruleIndex].ShortDescription.Text, hunk.NewCount, hunk.OldStart)
+		} else if hunk.OldCount > 1 {
+			message = fmt.Sprintf("%s would change lines %d-%d", rules.rules[ruleIndex].ShortDescription.Text, location.StartLine, location.EndLine)
+		}
+
+		replacement := sarifReplacement{DeletedRegion: deleted}
+		if inserted.Len() > 0 {
+			replacement.InsertedContent = &sarifMessage{Text: inserted.String()}
+		}
+
+		sr := sarifResult{
+			RuleID:    recipe,
+			RuleIndex: ruleIndex,
+			Level:     "warning",
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact, Region: &location}}},
+			Fixes: []sarifFix{{
+				Description: sarifMessage{Text: "Apply " + recipe},
+				ArtifactChanges: []sarifArtifactChange{{
+					ArtifactLocation: artifact,
+					Replacements:     []sarifReplacement{replacement},
+				}},
+			}},
+		}
+		if len(hunkRecipes[i]) > 1 {
+			sr.Properties = map[string]any{"recipes": hunkRecipes[i]}
+		}
+		results = append(results, sr)
+	}
+
+	return results
+}
+
+// sarifSearchResultOf returns the SARIF result of a search result
+// Search results are informational, so they are reported as notes without fixes.
+func sarifSearchResultOf(result SearchResult, rules *sarifRules) sarifResult {
+	ruleIndex := rules.index(result.Recipe)
+	message := result.Message
+	if message == "" {
+		message = rules.rules[ruleIndex].ShortDescription.Text
+	}
+
+	return sarifResult{
+		RuleID:    result.Recipe,
+		RuleIndex: ruleIndex,
+		Level:     "note",
+		Message:   sarifMessage{Text: message},
+		Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{
+				URI:       (&url.URL{Path: result.Path}).String(),
+				URIBaseID: sarifSourceRoot,
+			},
+			Region: &sarifRegion{
+				StartLine:   result.Line,
+				StartColumn: result.Column,
+				EndLine:     result.EndLine,
+				EndColumn:   result.EndColumn,
+			},
+		}}},
+	}
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaDialect is the JSON Schema version of the configuration schema
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of JSON Schema used to describe rewrite.yml
+// The same schema is written by the schema command for editors and checked by validation, see check.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 schemaTypes            `json:"type,omitempty"`
+	Const                interface{}            `json:"const,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Examples             []interface{}          `json:"examples,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	MinProperties        int                    `json:"minProperties,omitempty"`
+	MaxProperties        int                    `json:"maxProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	If                   *jsonSchema            `json:"if,omitempty"`
+	Then                 *jsonSchema            `json:"then,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+
+	// noun names the properties of an object in messages, e.g. key or option
+	noun string
+	// lenient makes unknown properties warnings rather than errors
+	lenient bool
+}
+
+// schemaTypes are the JSON types a value may have, written as a single string when there is one
+type schemaTypes []string
+
+// MarshalJSON implements json.Marshaler
+func (t schemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// schemaTypeNames are the names of JSON types in messages, in the terms of YAML
+var schemaTypeNames = map[string]string{
+	"object":  "a map",
+	"array":   "a list",
+	"string":  "a string",
+	"boolean": "a boolean",
+	"integer": "an integer",
+	"number":  "a number",
+	"null":    "empty",
+}
+
+// recipeOptionTypes maps the types of recipe options to JSON types
+var recipeOptionTypes = map[string]string{
+	"String":  "string",
+	"Boolean": "boolean",
+	"Integer": "integer",
+	"Number":  "number",
+}
+
+// ConfigurationSchema returns the JSON Schema of rewrite.yml with the options of every registered recipe
+// Recipe list entries naming a registered recipe are checked against the options of the recipe, which lets
+// editors complete and check them.
+func ConfigurationSchema() *jsonSchema {
+	return configurationSchema(RegisteredRecipes())
+}
+
+// documentSchema is the schema of the structure of a configuration document, without recipe options
+// Configuration files are checked against it while loading; options are checked by Validate.
+var documentSchema = configurationSchema(nil)
+
+// configurationSchema returns the schema of a configuration document describing the options of recipes
+func configurationSchema(recipes []*RecipeDescriptor) *jsonSchema {
+	stringList := &jsonSchema{Type: schemaTypes{"array"}, Items: &jsonSchema{Type: schemaTypes{"string"}}}
+
+	properties := configSchemaProperties()
+	for name, property := range map[string]*jsonSchema{
+		"type": {
+			Description: "The kind of document.",
+			Type:        schemaTypes{"string"},
+			Enum:        []interface{}{RecipeSpecType, StyleSpecType, CategorySpecType},
+		},
+		"name":                         {Description: "The name of the recipe, style or category the document declares.", Type: schemaTypes{"string"}},
+		"displayName":                  {Type: schemaTypes{"string"}},
+		"description":                  {Type: schemaTypes{"string"}},
+		"packageName":                  {Description: "The package whose recipes the category groups.", Type: schemaTypes{"string"}},
+		"tags":                         stringList,
+		"estimatedEffortPerOccurrence": {Type: schemaTypes{"string"}},
+		"recipes":                      {Description: "Declarative recipes.", Type: schemaTypes{"array"}, Items: &jsonSchema{Ref: "#/$defs/recipe"}},
+		"styles":                       {Description: "Styles.", Type: schemaTypes{"array"}, Items: &jsonSchema{Ref: "#/$defs/style"}},
+		"recipeList":                   {Description: "The recipes to activate.", Ref: "#/$defs/recipeList"},
+		"styleList":                    {Description: "The names of the styles to activate.", Type: schemaTypes{"array"}, Items: &jsonSchema{Type: schemaTypes{"string"}}},
+		"styleConfigs":                 {Description: "The style configurations of the style the document declares.", Ref: "#/$defs/styleConfigs"},
+	} {
+		properties[name] = property
+	}
+
+	entry := &jsonSchema{
+		Description:          "A recipe name, or a map of a recipe name to its options.",
+		Type:                 schemaTypes{"string", "object"},
+		MinProperties:        1,
+		MaxProperties:        1,
+		Properties:           map[string]*jsonSchema{},
+		AdditionalProperties: &jsonSchema{Type: schemaTypes{"object", "null"}},
+		noun:                 "recipe name",
+	}
+	defs := map[string]*jsonSchema{
+		"recipe": {
+			Description: "A declarative recipe.",
+			Type:        schemaTypes{"object"},
+			Properties: map[string]*jsonSchema{
+				"name":                         {Type: schemaTypes{"string"}},
+				"displayName":                  {Type: schemaTypes{"string"}},
+				"description":                  {Type: schemaTypes{"string"}},
+				"tags":                         stringList,
+				"estimatedEffortPerOccurrence": {Type: schemaTypes{"string"}},
+				"recipeList":                   {Ref: "#/$defs/recipeList"},
+			},
+			Required: []string{"name"},
+		},
+		"style": {
+			Description: "A style.",
+			Type:        schemaTypes{"object"},
+			Properties: map[string]*jsonSchema{
+				"name":         {Type: schemaTypes{"string"}},
+				"displayName":  {Type: schemaTypes{"string"}},
+				"description":  {Type: schemaTypes{"string"}},
+				"styleConfigs": {Ref: "#/$defs/styleConfigs"},
+			},
+			Required: []string{"name"},
+		},
+		"recipeList":   {Type: schemaTypes{"array"}, Items: entry},
+		"styleConfigs": styleConfigsSchema(),
+	}
+	for _, recipe := range recipes {
+		defs[recipe.Name] = recipeOptionsSchema(recipe)
+		entry.Properties[recipe.Name] = &jsonSchema{Ref: "#/$defs/" + recipe.Name}
+		entry.Examples = append(entry.Examples, recipe.Name)
+	}
+
+	return &jsonSchema{
+		Schema:      schemaDialect,
+		Title:       "rewrite.yml",
+		Description: "Recipes, styles and categories for rewrite-go, and the options of rewrite-go itself.",
+		Type:        schemaTypes{"object"},
+		Properties:  properties,
+		If: &jsonSchema{
+			Properties: map[string]*jsonSchema{"type": {Const: CategorySpecType}},
+			Required:   []string{"type"},
+		},
+		Then:    &jsonSchema{Required: []string{"packageName"}, noun: "key"},
+		Defs:    defs,
+		noun:    "key",
+		lenient: true,
+	}
+}
+
+// recipeOptionsSchema returns the schema of the options of a built-in recipe
+func recipeOptionsSchema(recipe *RecipeDescriptor) *jsonSchema {
+	schema := &jsonSchema{
+		Title:                recipe.DisplayName,
+		Description:          recipe.Description,
+		Type:                 schemaTypes{"object", "null"},
+		Properties:           map[string]*jsonSchema{},
+		AdditionalProperties: false,
+		noun:                 "option",
+	}
+	for _, option := range recipe.Options {
+		property := &jsonSchema{
+			Title:       option.DisplayName,
+			Description: option.Description,
+			Default:     option.Default,
+		}
+		if optionType, ok := recipeOptionTypes[option.Type]; ok {
+			property.Type = schemaTypes{optionType}
+		}
+		if option.Example != "" {
+			property.Examples = []interface{}{option.Example}
+		}
+		schema.Properties[option.Name] = property
+		if option.Required {
+			schema.Required = append(schema.Required, option.Name)
+		}
+	}
+	return schema
+}
+
+// styleConfigsSchema returns the schema of the style configurations of a style
+// The settings of each configuration are described by the fields of its part of a StyleSet, with the
+// defaults of DefaultStyleSet. Unknown configurations and settings are ignored when loading, so they are warnings.
+func styleConfigsSchema() *jsonSchema {
+	entry := &jsonSchema{
+		Description:   "A map of the name of a style configuration to its settings.",
+		Type:          schemaTypes{"object"},
+		MinProperties: 1,
+		MaxProperties: 1,
+		Properties:    map[string]*jsonSchema{},
+		noun:          "style configuration",
+		lenient:       true,
+	}
+	defaults := DefaultStyleSet()
+	for name, target := range styleConfigTypes {
+		settings := styleSettingsSchema(reflect.ValueOf(target(defaults)).Elem())
+		settings.Type = schemaTypes{"object", "null"}
+		entry.Properties[name] = settings
+		entry.Examples = append(entry.Examples, name)
+	}
+	sort.Slice(entry.Examples, func(i, j int) bool {
+		return entry.Examples[i].(string) < entry.Examples[j].(string)
+	})
+	return &jsonSchema{Type: schemaTypes{"array"}, Items: entry}
+}
+
+// styleSettingsSchema returns the schema of the settings of a style configuration from its default value
+func styleSettingsSchema(value reflect.Value) *jsonSchema {
+	schema := &jsonSchema{
+		Type:       schemaTypes{"object"},
+		Properties: map[string]*jsonSchema{},
+		noun:       "setting",
+		lenient:    true,
+	}
+	for i := 0; i < value.NumField(); i++ {
+		key, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("yaml"), ",")
+		field := value.Field(i)
+		property := &jsonSchema{Default: field.Interface()}
+		switch field.Kind() {
+		case reflect.Bool:
+			property.Type = schemaTypes{"boolean"}
+		case reflect.Int:
+			property.Type = schemaTypes{"integer"}
+		case reflect.Slice:
+			property.Type = schemaTypes{"array"}
+			property.Items = &jsonSchema{Type: schemaTypes{"string"}}
+		case reflect.Struct:
+			property = styleSettingsSchema(field)
+		}
+		schema.Properties[key] = property
+	}
+	return schema
+}
+
+// configSchemaProperties returns the properties of the options of rewrite-go that rewrite.yml may set
+// Both the yaml and the mapstructure names of Config fields are accepted.
+func configSchemaProperties() map[string]*jsonSchema {
+	properties := map[string]*jsonSchema{}
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		property := &jsonSchema{}
+		switch {
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			property.Type = schemaTypes{"string"}
+		case field.Type.Kind() == reflect.String:
+			property.Type = schemaTypes{"string"}
+		case field.Type.Kind() == reflect.Bool:
+			property.Type = schemaTypes{"boolean"}
+		case field.Type.Kind() == reflect.Int:
+			property.Type = schemaTypes{"integer"}
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+			property.Type = schemaTypes{"array"}
+			property.Items = &jsonSchema{Type: schemaTypes{"string"}}
+		}
+		for _, tag := range []string{"yaml", "mapstructure"} {
+			if key, _, _ := strings.Cut(field.Tag.Get(tag), ","); key != "" && key != "-" {
+				properties[key] = property
+			}
+		}
+	}
+	return properties
+}
+
+// WriteConfigurationSchema writes the schema of rewrite.yml as indented JSON
+func WriteConfigurationSchema(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(ConfigurationSchema())
+}
+
+// schemaViolation is a part of a YAML document that does not match a schema
+type schemaViolation struct {
+	node    *yaml.Node
+	message string
+	// unknown is set for unknown properties of a lenient schema, which are only warnings
+	unknown bool
+}
+
+// check returns the violations of a schema by a YAML node, named name in messages, or unnamed if name is empty
+// $ref is resolved against the $defs of root. Keys with empty values are treated as absent. Scalars are matched by value rather than by YAML tag, as
+// options are converted when recipes read them: the string "true" is a boolean and 42 is a string.
+func (s *jsonSchema) check(root *jsonSchema, name string, node *yaml.Node) []schemaViolation {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if s.Ref != "" {
+		return root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")].check(root, name, node)
+	}
+
+	var violations []schemaViolation
+	violate := func(node *yaml.Node, format string, args ...interface{}) {
+		violations = append(violations, schemaViolation{node: node, message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(node) {
+		var names []string
+		for _, t := range s.Type {
+			names = append(names, schemaTypeNames[t])
+		}
+		if node.Kind == yaml.ScalarNode && !isNullNode(node) {
+			violate(node, "%s must be %s, got %q", name, strings.Join(names, " or "), node.Value)
+		} else {
+			violate(node, "%s must be %s", name, strings.Join(names, " or "))
+		}
+		return violations
+	}
+	if s.Const != nil && (node.Kind != yaml.ScalarNode || node.Value != fmt.Sprint(s.Const)) {
+		violate(node, "%s must be %v", name, s.Const)
+	}
+	if len(s.Enum) > 0 && node.Kind == yaml.ScalarNode {
+		var values []string
+		for _, value := range s.Enum {
+			values = append(values, fmt.Sprint(value))
+		}
+		if !containsString(values, node.Value) {
+			violate(node, "%s must be one of %s, got %q", name, strings.Join(values, ", "), node.Value)
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		violations = append(violations, s.checkProperties(root, name, node)...)
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for _, item := range node.Content {
+				violations = append(violations, s.Items.check(root, name+" entry", item)...)
+			}
+		}
+	}
+
+	if s.If != nil && s.Then != nil && len(s.If.check(root, name, node)) == 0 {
+		violations = append(violations, s.Then.check(root, name, node)...)
+	}
+	return violations
+}
+
+// checkProperties returns the violations of the property constraints of a schema by a YAML map
+func (s *jsonSchema) checkProperties(root *jsonSchema, name string, node *yaml.Node) []schemaViolation {
+	var violations []schemaViolation
+	violate := func(node *yaml.Node, format string, args ...interface{}) {
+		violations = append(violations, schemaViolation{node: node, message: fmt.Sprintf(format, args...)})
+	}
+	noun := s.noun
+	if noun == "" {
+		noun = "key"
+	}
+
+	count := len(node.Content) / 2
+	if s.MinProperties > 0 && count < s.MinProperties {
+		violate(node, "%s must have at least %d %s", name, s.MinProperties, noun)
+	}
+	if s.MaxProperties > 0 && count > s.MaxProperties {
+		// The keys are likely misplaced, so they are not checked any further
+		violate(node, "%s must have at most %d %s, check the indentation", name, s.MaxProperties, noun)
+		return violations
+	}
+	for _, required := range s.Required {
+		if value := mappingValue(node, required); value == nil || isNullNode(value) {
+			if name == "" {
+				violate(node, "missing required %s %s", noun, required)
+			} else {
+				violate(node, "%s is missing required %s %s", name, noun, required)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for property := range s.Properties {
+		names = append(names, property)
+	}
+	sort.Strings(names)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		property, ok := s.Properties[key.Value]
+		// Empty values are the same as leaving a key out, as when loading
+		if ok && isNullNode(value) {
+			continue
+		}
+		if ok {
+			violations = append(violations, property.check(root, key.Value, value)...)
+			continue
+		}
+		switch additional := s.AdditionalProperties.(type) {
+		case bool:
+			if !additional {
+				violations = append(violations, schemaViolation{
+					node:    key,
+					message: fmt.Sprintf("unknown %s %q%s", noun, key.Value, didYouMean(key.Value, names)),
+					unknown: s.lenient,
+				})
+			}
+		case *jsonSchema:
+			violations = append(violations, additional.check(root, key.Value, value)...)
+		case nil:
+			if s.lenient {
+				violations = append(violations, schemaViolation{
+					node:    key,
+					message: fmt.Sprintf("unknown %s %q%s", noun, key.Value, didYouMean(key.Value, names)),
+					unknown: true,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// matches reports whether a YAML node is of one of the types
+func (t schemaTypes) matches(node *yaml.Node) bool {
+	for _, name := range t {
+		switch name {
+		case "object":
+			if node.Kind == yaml.MappingNode {
+				return true
+			}
+		case "array":
+			if node.Kind == yaml.SequenceNode {
+				return true
+			}
+		case "null":
+			if isNullNode(node) {
+				return true
+			}
+		case "string":
+			if node.Kind == yaml.ScalarNode && !isNullNode(node) {
+				return true
+			}
+		case "boolean":
+			if _, err := strconv.ParseBool(node.Value); node.Kind == yaml.ScalarNode && err == nil {
+				return true
+			}
+		case "integer":
+			if _, err := strconv.Atoi(strings.TrimSpace(node.Value)); node.Kind == yaml.ScalarNode && err == nil {
+				return true
+			}
+		case "number":
+			if _, err := strconv.ParseFloat(strings.TrimSpace(node.Value), 64); node.Kind == yaml.ScalarNode && err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCheckConfigurationDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []string
+	}{
+		{
+			name: "valid recipe",
+			document: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Valid
+recipeList:
+  - org.openrewrite.java.format.BlankLines
+`,
+		},
+		{
+			name:     "empty document",
+			document: "",
+		},
+		{
+			name: "unknown key",
+			document: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Unknown
+displayNme: Unknown
+`,
+			want: []string{`rewrite.yml:3:1: warning: unknown key "displayNme", did you mean displayName?`},
+		},
+		{
+			name: "unknown type",
+			document: `type: specs.openrewrite.org/v1/recipe
+name: com.example.Untyped
+`,
+			want: []string{`rewrite.yml:1:7: error: type must be one of specs.openrewrite.org/v1beta/recipe, specs.openrewrite.org/v1beta/style, specs.openrewrite.org/v1beta/category, got "specs.openrewrite.org/v1/recipe"`},
+		},
+		{
+			name: "wrong type",
+			document: `type: specs.openrewrite.org/v1beta/recipe
+name:
+  - com.example.List
+`,
+			want: []string{"rewrite.yml:3:3: error: name must be a string"},
+		},
+		{
+			name: "wrong type of a recipe list entry",
+			document: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Entries
+recipeList:
+  - - org.openrewrite.java.format.BlankLines
+`,
+			want: []string{"rewrite.yml:4:5: error: recipeList entry must be a string or a map"},
+		},
+		{
+			name: "category without a package",
+			document: `type: specs.openrewrite.org/v1beta/category
+name: Example
+`,
+			want: []string{"rewrite.yml:1:1: error: configuration document is missing required key packageName"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.document), &document); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range checkConfigurationDocument("rewrite.yml", &document) {
+				got = append(got, issue.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// searchContextLines is the number of lines shown around a search result
+const searchContextLines = 2
+
+// SearchResult marks a region of a source file that a search recipe matched
+// This mirrors the SearchResult marker from the Java version. Marking a region does not
+// change the content of a file, so search results are never written back by run.
+type SearchResult struct {
+	Recipe    string `json:"recipe"`
+	Path      string `json:"path"`
+	Message   string `json:"message,omitempty"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+
+	// Context holds the matched lines and the lines around them
+	Context []SearchContextLine `json:"context"`
+}
+
+// SearchContextLine is a numbered line of the context of a search result
+type SearchContextLine struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+	Match  bool   `json:"match,omitempty"`
+}
+
+// SearchRegion is a region [Start, End) of a source file's content, in byte offsets, that a recipe matched
+type SearchRegion struct {
+	Start   int
+	End     int
+	Message string
+}
+
+// markSearchResults returns a copy of a source file with the given regions marked as search results
+// Lines and columns are 1-based, columns count characters.
+func markSearchResults(sourceFile *SourceFile, recipe string, regions ...SearchRegion) *SourceFile {
+	marked := copySourceFile(sourceFile)
+	if len(regions) == 0 {
+		return marked
+	}
+
+	content := sourceFile.Content
+	lineStarts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	// A trailing line terminator does not start another line
+	lineCount := len(lineStarts)
+	if lineCount > 1 && lineStarts[lineCount-1] == len(content) {
+		lineCount--
+	}
+	position := func(offset int) (int, int) {
+		offset = min(max(offset, 0), len(content))
+		line := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset })
+		return line, utf8.RuneCountInString(content[lineStarts[line-1]:offset]) + 1
+	}
+	lineText := func(line int) string {
+		end := len(content)
+		if line < len(lineStarts) {
+			end = lineStarts[line] - 1
+		}
+		return strings.TrimRight(content[lineStarts[line-1]:end], "\r")
+	}
+
+	for _, region := range regions {
+		line, column := position(region.Start)
+		endLine, endColumn := position(region.End)
+		result := SearchResult{
+			Recipe:    recipe,
+			Path:      filepath.ToSlash(sourceFile.Path),
+			Message:   region.Message,
+			Line:      line,
+			Column:    column,
+			EndLine:   endLine,
+			EndColumn: endColumn,
+		}
+
+		first := max(line-searchContextLines, 1)
+		last := min(endLine+searchContextLines, lineCount)
+		for number := first; number <= last; number++ {
+			result.Context = append(result.Context, SearchContextLine{
+				Number: number,
+				Text:   lineText(number),
+				Match:  number >= line && number <= endLine,
+			})
+		}
+
+		marked.SearchResults = append(marked.SearchResults, result)
+	}
+
+	return marked
+}
+
+// formatSearchResult formats a search result as file:line:col followed by its context
+func formatSearchResult(result SearchResult) []string {
+	header := fmt.Sprintf("%s:%d:%d:", result.Path, result.Line, result.Column)
+	if result.Message != "" {
+		header += " " + result.Message
+	}
+	lines := []string{header + " (" + result.Recipe + ")"}
+
+	width := len(fmt.Sprint(result.EndLine + searchContextLines))
+	for _, line := range result.Context {
+		marker := " "
+		if line.Match {
+			marker = ">"
+		}
+		lines = append(lines, fmt.Sprintf("  %s %*d | %s", marker, width, line.Number, line.Text))
+	}
+	return lines
+}
+
+// writeSearchResults writes search results with their context, separated by blank lines
+func writeSearchResults(w io.Writer, results []SearchResult) error {
+	for i, result := range results {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		for _, line := range formatSearchResult(result) {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// logSearchResults logs the search results of a run
+func (r *Runner) logSearchResults(results *ResultsContainer) {
+	if len(results.SearchResults) == 0 {
+		return
+	}
+
+	r.Logger.Info("Found search results", "count", len(results.SearchResults))
+	for _, result := range results.SearchResults {
+		lines := formatSearchResult(result)
+		r.Logger.Info("Search result", "path", result.Path, "line", result.Line, "column", result.Column,
+			"recipe", result.Recipe, "message", result.Message, "context", strings.Join(lines[1:], "\n"))
+	}
+}
+
+// Search runs the active recipes and lists their search results without changing any file
+func (r *Runner) Search(ctx context.Context) error {
+	if r.Rewriter.Config.Skip {
+		r.Logger.Info("Skipping search execution")
+		return nil
+	}
+	startedAt := time.Now()
+
+	// Validate the requested reports before doing any work
+	_, err := parseReportTargets(r.Rewriter.Config.Reports)
+	if err != nil {
+		return configError(err)
+	}
+
+	// Load the environment
+	err = r.Rewriter.LoadEnvironment()
+	if err != nil {
+		return configError(fmt.Errorf("failed to load environment: %w", err))
+	}
+	err = r.validateEnvironment()
+	if err != nil {
+		return err
+	}
+
+	// Get the build root
+	buildRoot, err := r.Rewriter.GetBuildRoot()
+	if err != nil {
+		return fmt.Errorf("failed to get build root: %w", err)
+	}
+
+	r.Logger.Info("Searching project", "root", buildRoot)
+	err = r.recoverInterruptedRun(buildRoot)
+	if err != nil {
+		return err
+	}
+
+	// Find source files
+	sourceFiles, err := r.Rewriter.FindSourceFiles(ctx, buildRoot)
+	if err != nil {
+		return fmt.Errorf("failed to find source files: %w", err)
+	}
+
+	r.Logger.Info("Found source files to search", "count", len(sourceFiles))
+
+	if len(sourceFiles) == 0 {
+		r.Logger.Info("No source files found to search")
+		return nil
+	}
+
+	// Process the files, changes are ignored
+	results, err := r.processFiles(ctx, sourceFiles)
+	if err != nil {
+		return fmt.Errorf("failed to process files: %w", err)
+	}
+	if results.Interrupted {
+		return r.reportInterrupted(ctx, "search", startedAt, len(sourceFiles), results)
+	}
+
+	// Handle recipe errors, only the apply-successful policy carries on with the files without errors
+	if len(results.Errors) > 0 {
+		r.logRecipeErrors(results, len(sourceFiles))
+		if !r.Rewriter.Config.ApplySuccessfulChanges() {
+			if err := r.writeReports("search", startedAt, results); err != nil {
+				r.Logger.Warn("Failed to write reports", "error", err)
+			}
+			return recipeErrors(results.Errors)
+		}
+		r.Logger.Warn("Continuing with the search results of the files without errors")
+	}
+
+	err = r.exportDataTables(buildRoot, results)
+	if err != nil {
+		return err
+	}
+
+	if len(results.SearchResults) == 0 {
+		r.Logger.Info("No search results found")
+	} else {
+		err = writeSearchResults(os.Stdout, results.SearchResults)
+		if err != nil {
+			return fmt.Errorf("failed to print search results: %w", err)
+		}
+		r.Logger.Info("Found search results", "count", len(results.SearchResults))
+	}
+
+	if r.Rewriter.Config.Profile {
+		r.logProfile(results)
+	}
+
+	err = r.writeReports("search", startedAt, results)
+	if err != nil {
+		return err
+	}
+
+	if len(results.Errors) > 0 {
+		return recipeErrors(results.Errors)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Names of the style configurations, as in the Java version
+const (
+	TabsAndIndentsStyleName    = "org.openrewrite.java.style.TabsAndIndentsStyle"
+	ImportLayoutStyleName      = "org.openrewrite.java.style.ImportLayoutStyle"
+	BlankLinesStyleName        = "org.openrewrite.java.style.BlankLinesStyle"
+	WrappingAndBracesStyleName = "org.openrewrite.java.style.WrappingAndBracesStyle"
+)
+
+// TabsAndIndentsStyle configures the indentation of code
+type TabsAndIndentsStyle struct {
+	UseTabCharacter    bool `yaml:"useTabCharacter"`
+	TabSize            int  `yaml:"tabSize"`
+	IndentSize         int  `yaml:"indentSize"`
+	ContinuationIndent int  `yaml:"continuationIndent"`
+}
+
+// ImportLayoutStyle configures the order of imports and when they are folded into a star import
+// Layout lists the blocks of imports in order as in the Java version, e.g. "import java.*",
+// "<blank line>", "import all other imports" or "import static all other imports".
+type ImportLayoutStyle struct {
+	ClassCountToUseStarImport int      `yaml:"classCountToUseStarImport"`
+	NameCountToUseStarImport  int      `yaml:"nameCountToUseStarImport"`
+	Layout                    []string `yaml:"layout"`
+}
+
+// BlankLinesStyle configures the blank lines kept in code and required between declarations
+type BlankLinesStyle struct {
+	KeepMaximum BlankLinesKeepMaximum `yaml:"keepMaximum"`
+	Minimum     BlankLinesMinimum     `yaml:"minimum"`
+}
+
+// BlankLinesKeepMaximum is the number of consecutive blank lines kept
+type BlankLinesKeepMaximum struct {
+	InDeclarations   int `yaml:"inDeclarations"`
+	InCode           int `yaml:"inCode"`
+	BeforeEndOfBlock int `yaml:"beforeEndOfBlock"`
+}
+
+// BlankLinesMinimum is the number of blank lines required around declarations
+type BlankLinesMinimum struct {
+	BeforePackage    int `yaml:"beforePackage"`
+	AfterPackage     int `yaml:"afterPackage"`
+	BeforeImports    int `yaml:"beforeImports"`
+	AfterImports     int `yaml:"afterImports"`
+	AroundClass      int `yaml:"aroundClass"`
+	AfterClassHeader int `yaml:"afterClassHeader"`
+	AroundField      int `yaml:"aroundField"`
+	AroundMethod     int `yaml:"aroundMethod"`
+}
+
+// WrappingAndBracesStyle configures how long lines are wrapped
+type WrappingAndBracesStyle struct {
+	HardWrapAt     int  `yaml:"hardWrapAt"`
+	WrapLongLines  bool `yaml:"wrapLongLines"`
+	KeepLineBreaks bool `yaml:"keepLineBreaks"`
+}
+
+// StyleSet holds the style configurations recipes format code with
+// It starts from the IntelliJ defaults, and the style configurations of the active styles are applied
+// on top in order, each setting only the values it mentions.
+type StyleSet struct {
+	TabsAndIndents    TabsAndIndentsStyle
+	ImportLayout      ImportLayoutStyle
+	BlankLines        BlankLinesStyle
+	WrappingAndBraces WrappingAndBracesStyle
+}
+
+// DefaultStyleSet returns the style configurations used when no style sets them, those of IntelliJ IDEA
+func DefaultStyleSet() *StyleSet {
+	return &StyleSet{
+		TabsAndIndents: TabsAndIndentsStyle{TabSize: 4, IndentSize: 4, ContinuationIndent: 8},
+		ImportLayout: ImportLayoutStyle{
+			ClassCountToUseStarImport: 5,
+			NameCountToUseStarImport:  3,
+			Layout: []string{
+				"import all other imports",
+				"<blank line>",
+				"import javax.*",
+				"import java.*",
+				"<blank line>",
+				"import static all other imports",
+			},
+		},
+		BlankLines: BlankLinesStyle{
+			KeepMaximum: BlankLinesKeepMaximum{InDeclarations: 2, InCode: 2, BeforeEndOfBlock: 2},
+			Minimum:     BlankLinesMinimum{AfterPackage: 1, BeforeImports: 1, AfterImports: 1, AroundClass: 1, AroundMethod: 1},
+		},
+		WrappingAndBraces: WrappingAndBracesStyle{HardWrapAt: 120, KeepLineBreaks: true},
+	}
+}
+
+// styleConfigTypes are the style configurations by name, with a function returning the part of a StyleSet
+// each one sets. They describe the settings of each configuration in the configuration schema.
+var styleConfigTypes = map[string]func(set *StyleSet) interface{}{
+	TabsAndIndentsStyleName:    func(set *StyleSet) interface{} { return &set.TabsAndIndents },
+	ImportLayoutStyleName:      func(set *StyleSet) interface{} { return &set.ImportLayout },
+	BlankLinesStyleName:        func(set *StyleSet) interface{} { return &set.BlankLines },
+	WrappingAndBracesStyleName: func(set *StyleSet) interface{} { return &set.WrappingAndBraces },
+}
+
+// builtinStyles are the named styles available without declaring them
+var builtinStyles = map[string]func(set *StyleSet){
+	"org.openrewrite.java.IntelliJ": func(set *StyleSet) {
+		*set = *DefaultStyleSet()
+	},
+	"org.openrewrite.java.GoogleJavaFormat": func(set *StyleSet) {
+		set.TabsAndIndents = TabsAndIndentsStyle{TabSize: 2, IndentSize: 2, ContinuationIndent: 4}
+		set.ImportLayout = ImportLayoutStyle{
+			ClassCountToUseStarImport: 999,
+			NameCountToUseStarImport:  999,
+			Layout:                    []string{"import static all other imports", "<blank line>", "import all other imports"},
+		}
+		set.BlankLines.KeepMaximum = BlankLinesKeepMaximum{InDeclarations: 1, InCode: 1, BeforeEndOfBlock: 0}
+		set.WrappingAndBraces.HardWrapAt = 100
+	},
+}
+
+// StyleConfig is a style configuration of a style with its settings
+// In YAML it is a single-key map from the name of the configuration to its settings:
+//
+//	styleConfigs:
+//	  - org.openrewrite.java.style.TabsAndIndentsStyle:
+//	      useTabCharacter: true
+type StyleConfig struct {
+	Name string
+
+	// settings are decoded when the style is applied, onto the values of earlier styles
+	settings *yaml.Node
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (c *StyleConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode || len(node.Content) != 2 {
+		return fmt.Errorf("line %d: a style configuration must be a map of its name to its settings", node.Line)
+	}
+	c.Name = node.Content[0].Value
+	if !isNullNode(node.Content[1]) {
+		c.settings = node.Content[1]
+	}
+	return nil
+}
+
+// apply sets the values of a StyleSet that the style configuration mentions
+// Configurations rewrite-go does not know are ignored; validation reports them.
+func (c StyleConfig) apply(set *StyleSet) error {
+	target, ok := styleConfigTypes[c.Name]
+	if !ok || c.settings == nil {
+		return nil
+	}
+	err := c.settings.Decode(target(set))
+	if err != nil {
+		return fmt.Errorf("invalid settings of %s: %w", c.Name, err)
+	}
+	return nil
+}
+
+// resolveStyles returns the style configurations of the active styles of an environment
+// An active style without style configurations of its own refers to the last declared style of the
+// same name, or to a built-in style. Styles that are neither are skipped; validation reports them.
+func resolveStyles(env *Environment) (*StyleSet, error) {
+	set := DefaultStyleSet()
+	for _, active := range env.ActiveStyles {
+		style := findStyle(env, active)
+		if style == nil {
+			if builtin, ok := builtinStyles[active.Name]; ok {
+				builtin(set)
+			}
+			continue
+		}
+		for _, config := range style.StyleConfigs {
+			err := config.apply(set)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply style %s: %w", style.Name, err)
+			}
+		}
+	}
+	return set, nil
+}
+
+// findStyle returns the declaration of an active style with its style configurations, or nil
+func findStyle(env *Environment, active Style) *Style {
+	if len(active.StyleConfigs) > 0 {
+		return &active
+	}
+	for i := len(env.Styles) - 1; i >= 0; i-- {
+		if env.Styles[i].Name == active.Name && len(env.Styles[i].StyleConfigs) > 0 {
+			return &env.Styles[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// defaultEffortPerOccurrence is the effort a developer spends on a change when a recipe declares none
+// This is the default of the Java version.
+const defaultEffortPerOccurrence = 5 * time.Minute
+
+// isoDuration matches ISO-8601 durations such as PT5M or PT1H30M, as used by the Java version
+var isoDuration = regexp.MustCompile(`^PT(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?$`)
+
+// parseEffort parses an estimated effort, either as a Go duration like 10m or an ISO-8601 duration like PT10M
+func parseEffort(value string) (time.Duration, error) {
+	if match := isoDuration.FindStringSubmatch(value); match != nil && value != "PT" {
+		var effort time.Duration
+		for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+			if match[i+1] == "" {
+				continue
+			}
+			amount, err := strconv.ParseFloat(match[i+1], 64)
+			if err != nil {
+				return 0, err
+			}
+			effort += time.Duration(amount * float64(unit))
+		}
+		return effort, nil
+	}
+
+	effort, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid estimated effort %q, expected a duration like 10m or PT10M", value)
+	}
+	if effort < 0 {
+		return 0, fmt.Errorf("invalid estimated effort %q, must not be negative", value)
+	}
+	return effort, nil
+}
+
+// estimatedEffort returns the effort a developer would spend on one occurrence of a change the named recipe makes
+// A declarative recipe's estimatedEffortPerOccurrence takes precedence over the effort of a built-in
+// recipe, which in turn takes precedence over defaultEffortPerOccurrence.
+func (r *Rewriter) estimatedEffort(name string) (time.Duration, error) {
+	for _, declared := range r.Environment.Recipes {
+		if declared.Name == name && declared.EstimatedEffortPerOccurrence != "" {
+			effort, err := parseEffort(declared.EstimatedEffortPerOccurrence)
+			if err != nil {
+				return 0, fmt.Errorf("recipe %s: %w", name, err)
+			}
+			return effort, nil
+		}
+	}
+
+	if descriptor := LookupRecipe(name); descriptor != nil && descriptor.EstimatedEffortPerOccurrence > 0 {
+		return descriptor.EstimatedEffortPerOccurrence, nil
+	}
+
+	return defaultEffortPerOccurrence, nil
+}
+
+// ModuleTimeSaved is the estimated time saved in a module
+type ModuleTimeSaved struct {
+	Module       string
+	FilesChanged int
+	TimeSaved    time.Duration
+}
+
+// timeSavedByModule adds up the estimated time saved of every changed file per module
+// Modules are ordered by the time saved, most first.
+func timeSavedByModule(projectRoot string, results *ResultsContainer) []ModuleTimeSaved {
+	modules := map[string]*ModuleTimeSaved{}
+	cache := map[string]string{}
+
+	for _, category := range [][]Result{results.Generated, results.Deleted, results.Moved, results.RefactoredInPlace} {
+		for _, result := range category {
+			path := ""
+			if result.Before != nil {
+				path = result.Before.Path
+			} else if result.After != nil {
+				path = result.After.Path
+			}
+
+			module := moduleOf(projectRoot, path, cache)
+			if modules[module] == nil {
+				modules[module] = &ModuleTimeSaved{Module: module}
+			}
+			modules[module].FilesChanged++
+			modules[module].TimeSaved += result.TimeSaved
+		}
+	}
+
+	var sorted []ModuleTimeSaved
+	for _, module := range modules {
+		sorted = append(sorted, *module)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].TimeSaved != sorted[j].TimeSaved {
+			return sorted[i].TimeSaved > sorted[j].TimeSaved
+		}
+		return sorted[i].Module < sorted[j].Module
+	})
+	return sorted
+}
+
+// logTimeSaved logs the estimated time saved of a run, broken down per recipe and per module
+func (r *Runner) logTimeSaved(results *ResultsContainer) {
+	var total time.Duration
+	for _, category := range [][]Result{results.Generated, results.Deleted, results.Moved, results.RefactoredInPlace} {
+		for _, result := range category {
+			total += result.TimeSaved
+		}
+	}
+	r.Logger.Info("Estimate time saved", "total", formatDuration(total))
+
+	var recipes []*RecipeStats
+	for _, stats := range results.RecipeStats {
+		if stats.FilesChanged > 0 {
+			recipes = append(recipes, stats)
+		}
+	}
+	sort.SliceStable(recipes, func(i, j int) bool {
+		return recipes[i].TimeSaved > recipes[j].TimeSaved
+	})
+	if len(recipes) > 1 {
+		for _, stats := range recipes {
+			r.Logger.Info("Estimate time saved by recipe", "recipe", stats.Name, "timeSaved", formatDuration(stats.TimeSaved), "files", stats.FilesChanged)
+		}
+	}
+
+	modules := timeSavedByModule(results.ProjectRoot, results)
+	if len(modules) > 1 {
+		for _, module := range modules {
+			r.Logger.Info("Estimate time saved by module", "module", module.Module, "timeSaved", formatDuration(module.TimeSaved), "files", module.FilesChanged)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEffort(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"10m", 10 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"45s", 45 * time.Second},
+		{"0s", 0},
+		{"PT5M", 5 * time.Minute},
+		{"PT1H30M", 90 * time.Minute},
+		{"PT1H", time.Hour},
+		{"PT30S", 30 * time.Second},
+		{"PT1.5M", 90 * time.Second},
+		{"PT2H3M4S", 2*time.Hour + 3*time.Minute + 4*time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseEffort(tt.value)
+			if err != nil {
+				t.Fatalf("parseEffort(%q) failed: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseEffort(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEffortInvalid(t *testing.T) {
+	for _, value := range []string{"", "PT", "P1D", "PT1M1H", "5 minutes", "10", "-5m", "pt5m"} {
+		t.Run(value, func(t *testing.T) {
+			if got, err := parseEffort(value); err == nil {
+				t.Errorf("parseEffort(%q) = %v, want an error", value, got)
+			}
+		})
+	}
+}
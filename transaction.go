@@ -0,0 +1,490 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// operationKind identifies the kind of file system operation recorded in a journal
+type operationKind string
+
+const (
+	opWrite  operationKind = "write"
+	opRemove operationKind = "remove"
+	opMkdir  operationKind = "mkdir"
+	// opCommit marks a transaction whose changes are final, so only its backups remain to be removed
+	opCommit operationKind = "commit"
+)
+
+// File system operations of transactions, replaced in tests to simulate failures and crashes
+var (
+	renameFile = os.Rename
+	removeFile = os.Remove
+)
+
+// journalEntry records a file system operation before it is made, so it can be undone
+type journalEntry struct {
+	Kind operationKind `json:"op"`
+	// Path is the absolute path that is written, removed or created
+	Path string `json:"path,omitempty"`
+	// Backup holds the previous contents of Path, or is empty if Path did not exist before
+	Backup string `json:"backup,omitempty"`
+	// Staged holds the new contents of a write until they are renamed to Path
+	Staged string `json:"staged,omitempty"`
+}
+
+// transactionJournal returns the path of the journal of the transaction applying changes to a build root
+func transactionJournal(buildRoot string) string {
+	return filepath.Join(buildRoot, runHistoryRoot, "journal")
+}
+
+// Transaction applies file system changes atomically
+// Every write is staged to a temporary file in the target directory and renamed into
+// place, and every overwritten or removed file is kept as a backup until Commit.
+// If any operation fails, Rollback restores the tree to the state it was in before
+// the transaction started.
+//
+// With a journal file, every operation is recorded and synced before it is made. If the
+// process dies before the transaction completes, RecoverTransaction rolls it back, or
+// finishes the commit if it had started, when the next run starts.
+type Transaction struct {
+	journal     []journalEntry
+	done        bool
+	journalPath string
+	journalFile *os.File
+}
+
+// NewTransaction creates a new, empty Transaction journaled to journalPath, or only in memory if it is empty
+func NewTransaction(journalPath string) *Transaction {
+	return &Transaction{journalPath: journalPath}
+}
+
+// WriteFile atomically replaces the contents of path, creating parent directories as needed
+func (t *Transaction) WriteFile(path string, content []byte, mode os.FileMode) error {
+	if t.done {
+		return errors.New("transaction already completed")
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := t.mkdirAll(dir); err != nil {
+		return err
+	}
+
+	staged, err := tempName(dir, ".rewrite-tmp-")
+	if err != nil {
+		return err
+	}
+	entry := journalEntry{Kind: opWrite, Path: path, Staged: staged}
+	info, err := os.Lstat(path)
+	if err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("cannot write %s: is a directory", path)
+		}
+		if mode == 0 {
+			mode = info.Mode().Perm()
+		}
+		// Keep the original contents around so the write can be rolled back
+		entry.Backup, err = tempName(dir, "."+filepath.Base(path)+".rewrite-bak-")
+		if err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if mode == 0 {
+		mode = 0644
+	}
+
+	if err := t.record(entry); err != nil {
+		return err
+	}
+
+	// The new contents are staged before the backup is made, so as long as the staged file
+	// exists the original is untouched, see undo
+	if err := stageFile(entry.Staged, content, mode); err != nil {
+		return errors.Join(err, undo(entry))
+	}
+	if entry.Backup != "" {
+		if err := backupFile(path, entry.Backup, info.Mode().Perm()); err != nil {
+			return errors.Join(err, undo(entry))
+		}
+	}
+	if err := renameFile(entry.Staged, path); err != nil {
+		return errors.Join(fmt.Errorf("failed to rename %s into place: %w", path, err), undo(entry))
+	}
+	return nil
+}
+
+// Remove removes path, keeping it as a backup until the transaction is committed
+func (t *Transaction) Remove(path string) error {
+	if t.done {
+		return errors.New("transaction already completed")
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	// Renaming within the same directory is atomic, so the file is either
+	// present under its original name or under its backup name
+	backup, err := tempName(filepath.Dir(path), "."+filepath.Base(path)+".rewrite-bak-")
+	if err != nil {
+		return err
+	}
+	entry := journalEntry{Kind: opRemove, Path: path, Backup: backup}
+	if err := t.record(entry); err != nil {
+		return err
+	}
+	if err := renameFile(path, entry.Backup); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// Move moves oldPath to newPath with the given content and mode
+// The new file is written before the old one is removed so the content is never lost.
+func (t *Transaction) Move(oldPath, newPath string, content []byte, mode os.FileMode) error {
+	if mode == 0 {
+		if info, err := os.Lstat(oldPath); err == nil {
+			mode = info.Mode().Perm()
+		}
+	}
+	if err := t.WriteFile(newPath, content, mode); err != nil {
+		return err
+	}
+	return t.Remove(oldPath)
+}
+
+// Commit makes the transaction permanent by discarding all backups
+// The commit is journaled first, so a commit that is interrupted is finished rather than rolled back.
+// If the commit cannot be journaled nothing is discarded and the transaction can still be rolled back.
+// Once the commit is journaled the changes are permanent, and failures to discard backups are
+// returned as a *CommitCleanupError.
+func (t *Transaction) Commit() error {
+	if t.done {
+		return errors.New("transaction already completed")
+	}
+	if err := t.record(journalEntry{Kind: opCommit}); err != nil {
+		return fmt.Errorf("failed to journal commit: %w", err)
+	}
+	t.done = true
+
+	var errs []error
+	for _, entry := range t.journal {
+		if err := finish(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	t.journal = nil
+
+	if err := errors.Join(append(errs, t.closeJournal(len(errs) == 0))...); err != nil {
+		return &CommitCleanupError{Err: err}
+	}
+	return nil
+}
+
+// CommitCleanupError is returned by Commit when the changes are committed but backups or the journal
+// could not be removed. The journal is kept in that case, so the next run finishes the cleanup.
+type CommitCleanupError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *CommitCleanupError) Error() string {
+	return fmt.Sprintf("failed to clean up after commit: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *CommitCleanupError) Unwrap() error {
+	return e.Err
+}
+
+// Rollback undoes every completed operation in reverse order
+func (t *Transaction) Rollback() error {
+	if t.done {
+		return errors.New("transaction already completed")
+	}
+	t.done = true
+
+	var errs []error
+	for i := len(t.journal) - 1; i >= 0; i-- {
+		if err := undo(t.journal[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	t.journal = nil
+
+	return errors.Join(append(errs, t.closeJournal(len(errs) == 0))...)
+}
+
+// RecoverTransaction completes a transaction that was interrupted, e.g. by a crash, using its journal
+// A transaction that was committing is finished, any other is rolled back. found is false if there was
+// no journal. The journal is kept if recovery fails, so it can be retried.
+func RecoverTransaction(journalPath string) (found bool, committed bool, err error) {
+	content, err := os.ReadFile(journalPath)
+	if os.IsNotExist(err) {
+		return false, false, nil
+	}
+	if err != nil {
+		return true, false, fmt.Errorf("failed to read journal %s: %w", journalPath, err)
+	}
+
+	var entries []journalEntry
+	for _, line := range strings.Split(string(content), "\n") {
+		var entry journalEntry
+		// The last line is incomplete if the process died while writing it, its operation was not made
+		if json.Unmarshal([]byte(line), &entry) != nil {
+			continue
+		}
+		if entry.Kind == opCommit {
+			committed = true
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	var errs []error
+	if committed {
+		for _, entry := range entries {
+			if err := finish(entry); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	} else {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if err := undo(entries[i]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return true, committed, errors.Join(errs...)
+	}
+
+	err = os.Remove(journalPath)
+	if err != nil && !os.IsNotExist(err) {
+		return true, committed, fmt.Errorf("failed to remove journal %s: %w", journalPath, err)
+	}
+	return true, committed, nil
+}
+
+// record adds an operation to the journal, syncing it to the journal file before the operation is made
+func (t *Transaction) record(entry journalEntry) error {
+	if entry.Kind != opCommit {
+		t.journal = append(t.journal, entry)
+	}
+	if t.journalPath == "" {
+		return nil
+	}
+
+	if t.journalFile == nil {
+		err := os.MkdirAll(filepath.Dir(t.journalPath), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create journal directory: %w", err)
+		}
+		// A journal left by an interrupted run must be recovered first, it is never overwritten
+		t.journalFile, err = os.OpenFile(t.journalPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create journal: %w", err)
+		}
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to journal %s: %w", entry.Path, err)
+	}
+	if _, err := t.journalFile.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to journal %s: %w", entry.Path, err)
+	}
+	if err := t.journalFile.Sync(); err != nil {
+		return fmt.Errorf("failed to journal %s: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// closeJournal closes the journal file, and removes it once the transaction no longer needs recovery
+func (t *Transaction) closeJournal(remove bool) error {
+	if t.journalFile == nil {
+		return nil
+	}
+	err := t.journalFile.Close()
+	t.journalFile = nil
+	if err != nil {
+		return fmt.Errorf("failed to close journal: %w", err)
+	}
+	if remove {
+		if err := os.Remove(t.journalPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// undo reverts a journaled operation, whether or not it was completed
+// Operations are recorded before they are made, so undo looks at the files to see how far one got: as
+// long as the staged file of a write exists, it has not been renamed into place.
+func undo(entry journalEntry) error {
+	var errs []error
+	switch entry.Kind {
+	case opWrite:
+		if exists(entry.Staged) {
+			errs = append(errs, removeExisting(entry.Staged), removeExisting(entry.Backup))
+		} else if entry.Backup == "" {
+			errs = append(errs, removeExisting(entry.Path))
+		} else if exists(entry.Backup) {
+			if err := renameFile(entry.Backup, entry.Path); err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s: %w", entry.Path, err))
+			}
+		}
+	case opRemove:
+		if exists(entry.Backup) {
+			if err := renameFile(entry.Backup, entry.Path); err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s: %w", entry.Path, err))
+			}
+		}
+	case opMkdir:
+		if err := removeExisting(entry.Path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove directory %s: %w", entry.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// finish removes the files a committed operation kept for a rollback
+func finish(entry journalEntry) error {
+	err := errors.Join(removeExisting(entry.Staged), removeExisting(entry.Backup))
+	if err != nil {
+		return fmt.Errorf("failed to remove backup of %s: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// mkdirAll creates dir and any missing parents, journaling each directory it creates
+func (t *Transaction) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		info, err := os.Stat(d)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("cannot create directory %s: %s is not a directory", dir, d)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to stat %s: %w", d, err)
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	// Create from the outermost missing directory inwards
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := t.record(journalEntry{Kind: opMkdir, Path: missing[i]}); err != nil {
+			return err
+		}
+		if err := os.Mkdir(missing[i], 0755); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("failed to create directory %s: %w", missing[i], err)
+		}
+	}
+
+	return nil
+}
+
+// backupFile copies path to the backup file
+func backupFile(path, backup string, mode os.FileMode) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s for backup: %w", path, err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create backup for %s: %w", path, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := dst.Chmod(mode); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return nil
+}
+
+// stageFile writes content to the synced staging file
+func stageFile(staged string, content []byte, mode os.FileMode) error {
+	tmp, err := os.OpenFile(staged, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file %s: %w", staged, err)
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	return nil
+}
+
+// tempName returns a name for a temporary file in dir, which is journaled before the file is created
+func tempName(dir, prefix string) (string, error) {
+	var random [8]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", fmt.Errorf("failed to generate a temporary file name: %w", err)
+	}
+	return filepath.Join(dir, prefix+hex.EncodeToString(random[:])), nil
+}
+
+// exists reports whether path is set and exists
+func exists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// removeExisting removes path, ignoring empty paths and missing files
+func removeExisting(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := removeFile(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
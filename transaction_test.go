@@ -0,0 +1,332 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// errInjected is returned by file system operations that tests make fail
+var errInjected = errors.New("injected failure")
+
+// crash is panicked with by file system operations that tests make die, leaving the tree as it was at that point
+type crash struct{}
+
+// writeTree creates files with the given contents under dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the contents of every file under dir by slash-separated relative path
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// assertTree fails unless dir holds exactly the given files
+func assertTree(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	got := readTree(t, dir)
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			t.Errorf("unexpected file %s", name)
+		}
+	}
+}
+
+// assertNoDir fails if path exists
+func assertNoDir(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s still exists", path)
+	}
+}
+
+// failNthRename makes the nth rename fail with result, then restores os.Rename when the test ends
+func failNthRename(t *testing.T, n int, result func() error) {
+	t.Helper()
+	calls := 0
+	renameFile = func(oldPath, newPath string) error {
+		calls++
+		if calls == n {
+			return result()
+		}
+		return os.Rename(oldPath, newPath)
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+}
+
+// runCrashing runs f, recovering the crash it is expected to die with
+func runCrashing(t *testing.T, f func()) {
+	t.Helper()
+	defer func() {
+		if _, ok := recover().(crash); !ok {
+			t.Fatal("expected a crash")
+		}
+	}()
+	f()
+}
+
+// initialTree is the tree the transaction tests start from
+var initialTree = map[string]string{
+	"a.txt":     "a",
+	"b.txt":     "b",
+	"dir/c.txt": "c",
+}
+
+func TestTransactionCommit(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, ".rewrite", "journal")
+	writeTree(t, dir, initialTree)
+
+	tx := NewTransaction(journal)
+	if err := tx.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.WriteFile(filepath.Join(dir, "new", "d.txt"), []byte("d"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Move(filepath.Join(dir, "b.txt"), filepath.Join(dir, "moved", "b.txt"), []byte("B"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Remove(filepath.Join(dir, "dir", "c.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertTree(t, dir, map[string]string{"a.txt": "A", "new/d.txt": "d", "moved/b.txt": "B"})
+}
+
+func TestTransactionRollbackAfterFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, ".rewrite", "journal")
+	writeTree(t, dir, initialTree)
+
+	tx := NewTransaction(journal)
+	if err := tx.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.WriteFile(filepath.Join(dir, "new", "d.txt"), []byte("d"), 0); err != nil {
+		t.Fatal(err)
+	}
+	// A directory cannot be created below a file
+	if err := tx.WriteFile(filepath.Join(dir, "b.txt", "e.txt"), []byte("e"), 0); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertTree(t, dir, initialTree)
+	assertNoDir(t, filepath.Join(dir, "new"))
+	assertNoDir(t, journal)
+}
+
+func TestTransactionRollbackAfterFailedRename(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, ".rewrite", "journal")
+	writeTree(t, dir, initialTree)
+
+	tx := NewTransaction(journal)
+	if err := tx.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0); err != nil {
+		t.Fatal(err)
+	}
+	failNthRename(t, 1, func() error { return errInjected })
+	if err := tx.WriteFile(filepath.Join(dir, "b.txt"), []byte("B"), 0); !errors.Is(err, errInjected) {
+		t.Fatalf("expected the rename to fail, got %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertTree(t, dir, initialTree)
+	assertNoDir(t, journal)
+}
+
+func TestTransactionRollbackAfterFailedRemove(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, ".rewrite", "journal")
+	writeTree(t, dir, initialTree)
+
+	tx := NewTransaction(journal)
+	if err := tx.Remove(filepath.Join(dir, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.WriteFile(filepath.Join(dir, "dir", "c.txt"), []byte("C"), 0); err != nil {
+		t.Fatal(err)
+	}
+	failNthRename(t, 1, func() error { return errInjected })
+	if err := tx.Remove(filepath.Join(dir, "b.txt")); !errors.Is(err, errInjected) {
+		t.Fatalf("expected the remove to fail, got %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertTree(t, dir, initialTree)
+	assertNoDir(t, journal)
+}
+
+func TestTransactionRollbackAfterFailedCommit(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, ".rewrite", "journal")
+	writeTree(t, dir, initialTree)
+
+	tx := NewTransaction(journal)
+	if err := tx.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.WriteFile(filepath.Join(dir, "new", "d.txt"), []byte("d"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Remove(filepath.Join(dir, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The commit cannot be journaled, so the backups must be kept for the rollback
+	tx.journalFile.Close()
+	readOnly, err := os.Open(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.journalFile = readOnly
+	err = tx.Commit()
+	var cleanupErr *CommitCleanupError
+	if err == nil || errors.As(err, &cleanupErr) {
+		t.Fatalf("Commit() = %v, want a failure to journal the commit", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertTree(t, dir, initialTree)
+	assertNoDir(t, filepath.Join(dir, "new"))
+	assertNoDir(t, journal)
+}
+
+func TestRecoverTransactionRollsBackInterruptedRun(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, ".rewrite", "journal")
+	writeTree(t, dir, initialTree)
+
+	// The process dies after some changes, without committing or rolling back
+	tx := NewTransaction(journal)
+	if err := tx.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.WriteFile(filepath.Join(dir, "new", "deeper", "d.txt"), []byte("d"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Remove(filepath.Join(dir, "dir", "c.txt")); err != nil {
+		t.Fatal(err)
+	}
+	tx.journalFile.Close()
+
+	found, committed, err := RecoverTransaction(journal)
+	if err != nil || !found || committed {
+		t.Fatalf("RecoverTransaction() = %v, %v, %v, want a rollback", found, committed, err)
+	}
+
+	assertTree(t, dir, initialTree)
+	assertNoDir(t, filepath.Join(dir, "new"))
+	assertNoDir(t, journal)
+}
+
+func TestRecoverTransactionCrashBeforeRename(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, ".rewrite", "journal")
+	writeTree(t, dir, initialTree)
+
+	// The process dies with the new contents staged and the original backed up
+	tx := NewTransaction(journal)
+	if err := tx.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0); err != nil {
+		t.Fatal(err)
+	}
+	failNthRename(t, 1, func() error { panic(crash{}) })
+	runCrashing(t, func() {
+		tx.WriteFile(filepath.Join(dir, "b.txt"), []byte("B"), 0)
+	})
+	tx.journalFile.Close()
+
+	if _, _, err := RecoverTransaction(journal); err != nil {
+		t.Fatal(err)
+	}
+
+	assertTree(t, dir, initialTree)
+	assertNoDir(t, journal)
+}
+
+func TestRecoverTransactionFinishesInterruptedCommit(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, ".rewrite", "journal")
+	writeTree(t, dir, initialTree)
+
+	tx := NewTransaction(journal)
+	if err := tx.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Remove(filepath.Join(dir, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The process dies after the commit is journaled, while removing the first backup
+	removeFile = func(path string) error {
+		if strings.Contains(filepath.Base(path), ".rewrite-bak-") {
+			panic(crash{})
+		}
+		return os.Remove(path)
+	}
+	defer func() { removeFile = os.Remove }()
+	runCrashing(t, func() { tx.Commit() })
+	tx.journalFile.Close()
+	removeFile = os.Remove
+
+	found, committed, err := RecoverTransaction(journal)
+	if err != nil || !found || !committed {
+		t.Fatalf("RecoverTransaction() = %v, %v, %v, want a finished commit", found, committed, err)
+	}
+
+	assertTree(t, dir, map[string]string{"a.txt": "A", "dir/c.txt": "c"})
+	assertNoDir(t, journal)
+}
+
+func TestRecoverTransactionWithoutJournal(t *testing.T) {
+	found, _, err := RecoverTransaction(filepath.Join(t.TempDir(), "journal"))
+	if err != nil || found {
+		t.Fatalf("RecoverTransaction() = %v, %v, want nothing to recover", found, err)
+	}
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severities of validation issues
+const (
+	// SeverityError marks a problem that makes the configuration do something other than intended
+	SeverityError = "error"
+	// SeverityWarning marks a problem that is likely a mistake but does not change what is run
+	SeverityWarning = "warning"
+)
+
+// maxSuggestions is the number of similar names suggested for an unknown name
+const maxSuggestions = 3
+
+// configPosition is where a value is declared in a configuration file
+type configPosition struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String returns the position as file:line:column, or an empty string if it is not known
+func (p configPosition) String() string {
+	if p.File == "" {
+		return ""
+	}
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// nodePosition returns the position of a YAML node in a configuration file
+func nodePosition(file string, node *yaml.Node) configPosition {
+	return configPosition{File: file, Line: node.Line, Column: node.Column}
+}
+
+// ValidationIssue is a problem found in the configuration
+type ValidationIssue struct {
+	Severity string
+	Position configPosition
+	Message  string
+}
+
+// String returns the issue as position: severity: message, as compilers report problems
+func (i ValidationIssue) String() string {
+	if position := i.Position.String(); position != "" {
+		return fmt.Sprintf("%s: %s: %s", position, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+}
+
+// ValidationError is the error of a configuration with validation errors
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	if len(e.Issues) == 1 {
+		return e.Issues[0].String()
+	}
+	return fmt.Sprintf("configuration has %d errors, the first: %s", len(e.Issues), e.Issues[0])
+}
+
+// validationErrors returns the issues of the error severity
+func validationErrors(issues []ValidationIssue) []ValidationIssue {
+	var errs []ValidationIssue
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	return errs
+}
+
+// checkConfigurationDocument checks a document of a configuration file against the configuration schema
+// Values of the wrong kind are errors, as the document cannot be loaded. Unknown keys are warnings, since
+// they are ignored when loading.
+func checkConfigurationDocument(file string, document *yaml.Node) []ValidationIssue {
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || isNullNode(document.Content[0]) {
+		return nil
+	}
+
+	var issues []ValidationIssue
+	for _, violation := range documentSchema.check(documentSchema, "configuration document", document.Content[0]) {
+		severity := SeverityError
+		if violation.unknown {
+			severity = SeverityWarning
+		}
+		issues = append(issues, ValidationIssue{Severity: severity, Position: nodePosition(file, violation.node), Message: violation.message})
+	}
+	return issues
+}
+
+// isNullNode reports whether a YAML node is an empty value, which is the same as leaving it out
+func isNullNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// mappingValue returns the value of a key of a YAML map, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// containsString reports whether a slice holds a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the loaded environment against the recipes that are available
+// It reports active recipes and recipe list entries that do not resolve to a recipe, built-in recipes
+// missing required options or configured with unknown options or values of the wrong type, recipes
+// declared more than once, active recipe selectors that select nothing, and active styles that are
+// neither declared nor built in. Issues found while loading the configuration are included.
+func (r *Rewriter) Validate() []ValidationIssue {
+	env := r.Environment
+	issues := append([]ValidationIssue(nil), env.Issues...)
+	available := availableRecipes(env)
+	report := func(severity string, position configPosition, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Severity: severity, Position: position, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, selector := range r.Config.GetActiveRecipes() {
+		if (strings.HasPrefix(selector, tagSelectorPrefix) || isRecipeGlob(selector)) && len(selectRecipes(selector, available)) == 0 {
+			report(SeverityError, configPosition{}, "active recipe selector %q matches no recipes", selector)
+		}
+	}
+
+	for _, recipe := range env.ActiveRecipes {
+		if resolveRecipe(available, recipe.Name) == nil {
+			report(SeverityError, recipe.position, "unknown active recipe %s%s", recipe.Name, suggestRecipes(available, recipe.Name))
+			continue
+		}
+		for _, problem := range validateRecipeOptions(available, recipe.Name, recipe.Options) {
+			report(problem.Severity, recipe.position, "%s", problem.Message)
+		}
+	}
+
+	declared := map[string]Recipe{}
+	for _, recipe := range env.Recipes {
+		if !recipe.declared {
+			continue
+		}
+		if previous, ok := declared[recipe.Name]; ok {
+			report(SeverityError, recipe.position, "recipe %s is declared more than once, first at %s", recipe.Name, previous.position)
+		} else {
+			declared[recipe.Name] = recipe
+			if LookupRecipe(recipe.Name) != nil {
+				report(SeverityWarning, recipe.position, "recipe %s is also built in, the declaration takes precedence", recipe.Name)
+			}
+		}
+
+		for _, entry := range recipe.RecipeList {
+			if resolveRecipe(available, entry.Name) == nil {
+				report(SeverityError, entry.position, "recipe %s refers to unknown recipe %s%s", recipe.Name, entry.Name, suggestRecipes(available, entry.Name))
+				continue
+			}
+			for _, problem := range validateRecipeOptions(available, entry.Name, entry.Options) {
+				report(problem.Severity, entry.position, "%s", problem.Message)
+			}
+		}
+	}
+
+	styles := knownStyleNames(env)
+	for _, style := range env.ActiveStyles {
+		if !style.declared && !containsString(styles, style.Name) {
+			report(SeverityWarning, style.position, "unknown active style %s is ignored%s", style.Name, didYouMean(style.Name, styles))
+		}
+	}
+
+	categories := map[string]bool{}
+	for _, category := range env.Categories {
+		if categories[category.PackageName] {
+			report(SeverityWarning, category.position, "category of package %s is declared more than once", category.PackageName)
+		}
+		categories[category.PackageName] = true
+	}
+
+	return issues
+}
+
+// knownStyleNames returns the names of the declared and built-in styles, once each in name order
+func knownStyleNames(env *Environment) []string {
+	var names []string
+	for _, style := range env.Styles {
+		if style.declared && !containsString(names, style.Name) {
+			names = append(names, style.Name)
+		}
+	}
+	for name := range builtinStyles {
+		if !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveRecipe returns the available recipe with exactly the given name, or nil
+// Recipe names are matched exactly when running, so a name that only differs in case does not resolve.
+func resolveRecipe(available []*AvailableRecipe, name string) *AvailableRecipe {
+	for _, recipe := range available {
+		if recipe.Name == name && !recipe.Unavailable {
+			return recipe
+		}
+	}
+	return nil
+}
+
+// validateRecipeOptions checks the options a recipe is configured with against the schema of its options
+func validateRecipeOptions(available []*AvailableRecipe, name string, values map[string]interface{}) []ValidationIssue {
+	if recipe := resolveRecipe(available, name); recipe.Declared {
+		if len(values) > 0 {
+			return []ValidationIssue{{Severity: SeverityWarning, Message: fmt.Sprintf("options of declarative recipe %s are ignored", name)}}
+		}
+		return nil
+	}
+
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	var node yaml.Node
+	err := node.Encode(values)
+	if err != nil {
+		return []ValidationIssue{{Severity: SeverityError, Message: fmt.Sprintf("recipe %s: invalid options: %v", name, err)}}
+	}
+
+	schema := recipeOptionsSchema(LookupRecipe(name))
+	var issues []ValidationIssue
+	for _, violation := range schema.check(schema, "", &node) {
+		issues = append(issues, ValidationIssue{Severity: SeverityError, Message: fmt.Sprintf("recipe %s: %s", name, violation.message)})
+	}
+	return issues
+}
+
+// suggestRecipes returns a did-you-mean note with the available recipes whose names are close to name
+func suggestRecipes(available []*AvailableRecipe, name string) string {
+	var names []string
+	for _, recipe := range available {
+		if !recipe.Unavailable {
+			names = append(names, recipe.Name)
+		}
+	}
+	return didYouMean(name, names)
+}
+
+// didYouMean returns a note suggesting the candidates closest to name, or an empty string if none is close
+// Candidates are compared ignoring case, and close means at most as many characters differ as a third of
+// the last dotted segment of name, so the long shared package of recipe and style names does not count.
+func didYouMean(name string, candidates []string) string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	var suggestions []suggestion
+	limit := max(len(name[strings.LastIndex(name, ".")+1:])/3, 2)
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if distance <= limit && candidate != name {
+			suggestions = append(suggestions, suggestion{candidate, distance})
+		}
+	}
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+	var names []string
+	for i := 0; i < len(suggestions) && i < maxSuggestions; i++ {
+		names = append(names, suggestions[i].name)
+	}
+	return fmt.Sprintf(", did you mean %s?", strings.Join(names, " or "))
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// validateEnvironment checks the configuration before a run
+// Errors fail the run when failOnInvalidActiveRecipes is set, otherwise every issue is logged as a warning.
+// This mirrors the recipe validation of AbstractRewriteMojo.
+func (r *Runner) validateEnvironment() error {
+	issues := r.Rewriter.Validate()
+	errs := validationErrors(issues)
+	if r.Rewriter.Config.FailOnInvalidActiveRecipes && len(errs) > 0 {
+		for _, issue := range errs {
+			r.logValidationIssue(slog.LevelError, issue)
+		}
+		return configError(&ValidationError{Issues: errs})
+	}
+
+	for _, issue := range issues {
+		r.logValidationIssue(slog.LevelWarn, issue)
+	}
+	if len(errs) > 0 {
+		r.Logger.Warn("Continuing with an invalid configuration, set failOnInvalidActiveRecipes to fail instead")
+	}
+	return nil
+}
+
+// logValidationIssue logs a validation issue at the given level
+func (r *Runner) logValidationIssue(level slog.Level, issue ValidationIssue) {
+	var args []interface{}
+	if position := issue.Position.String(); position != "" {
+		args = append(args, "at", position)
+	}
+	r.Logger.Log(context.Background(), level, issue.Message, args...)
+}
+
+// Validate checks the configuration and writes every issue found to stdout
+// It fails with a configuration error if any issue is an error, regardless of failOnInvalidActiveRecipes.
+func (r *Runner) Validate() error {
+	err := r.Rewriter.LoadEnvironment()
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		writeValidationIssues(os.Stdout, validationErr.Issues)
+		return configError(err)
+	}
+	if err != nil {
+		return configError(fmt.Errorf("failed to load environment: %w", err))
+	}
+
+	issues := r.Rewriter.Validate()
+	writeValidationIssues(os.Stdout, issues)
+	if errs := validationErrors(issues); len(errs) > 0 {
+		return configError(&ValidationError{Issues: errs})
+	}
+	return nil
+}
+
+// writeValidationIssues writes validation issues one per line, followed by a summary
+func writeValidationIssues(w io.Writer, issues []ValidationIssue) {
+	for _, issue := range issues {
+		fmt.Fprintln(w, issue)
+	}
+	errs := len(validationErrors(issues))
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "The configuration is valid.")
+		return
+	}
+	fmt.Fprintf(w, "Found %d errors and %d warnings.\n", errs, len(issues)-errs)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// validateConfiguration loads a rewrite.yml with the given content and active recipes and returns the issues reported,
+// with positions relative to the directory of the file
+func validateConfiguration(t *testing.T, content string, activeRecipes ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rewrite.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rewriter := NewRewriter(&Config{ConfigLocation: path, ActiveRecipes: activeRecipes}, dir)
+	var issues []ValidationIssue
+	err := rewriter.LoadEnvironment()
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		issues = validationErr.Issues
+	case err != nil:
+		t.Fatal(err)
+	default:
+		issues = rewriter.Validate()
+	}
+
+	var reported []string
+	for _, issue := range issues {
+		reported = append(reported, strings.ReplaceAll(issue.String(), dir+string(filepath.Separator), ""))
+	}
+	return reported
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		activeRecipes []string
+		want          []string
+	}{
+		{
+			name: "valid",
+			content: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Valid
+recipeList:
+  - org.openrewrite.text.FindAndReplace:
+      find: foo
+      replace: bar
+      regex: true
+`,
+			activeRecipes: []string{"com.example.Valid"},
+		},
+		{
+			name:          "unknown active recipe",
+			content:       "",
+			activeRecipes: []string{"org.openrewrite.text.FindAndReplaec"},
+			want:          []string{"error: unknown active recipe org.openrewrite.text.FindAndReplaec, did you mean org.openrewrite.text.FindAndReplace?"},
+		},
+		{
+			name: "unknown recipe in a recipe list",
+			content: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Typo
+recipeList:
+  - org.openrewrite.java.format.BlankLine
+`,
+			want: []string{"rewrite.yml:4:5: error: recipe com.example.Typo refers to unknown recipe org.openrewrite.java.format.BlankLine, did you mean org.openrewrite.java.format.BlankLines?"},
+		},
+		{
+			name: "unknown recipe without a close name",
+			content: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Unknown
+recipeList:
+  - org.openrewrite.java.format.SortMembers
+`,
+			want: []string{"rewrite.yml:4:5: error: recipe com.example.Unknown refers to unknown recipe org.openrewrite.java.format.SortMembers"},
+		},
+		{
+			name: "missing required option",
+			content: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Missing
+recipeList:
+  - org.openrewrite.text.FindAndReplace:
+      replace: bar
+`,
+			want: []string{"rewrite.yml:4:5: error: recipe org.openrewrite.text.FindAndReplace: missing required option find"},
+		},
+		{
+			name: "mistyped option",
+			content: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Mistyped
+recipeList:
+  - org.openrewrite.text.FindAndReplace:
+      find: foo
+      regex: maybe
+`,
+			want: []string{`rewrite.yml:4:5: error: recipe org.openrewrite.text.FindAndReplace: regex must be a boolean, got "maybe"`},
+		},
+		{
+			name: "unknown option",
+			content: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.UnknownOption
+recipeList:
+  - org.openrewrite.text.FindAndReplace:
+      find: foo
+      regexp: true
+`,
+			want: []string{`rewrite.yml:4:5: error: recipe org.openrewrite.text.FindAndReplace: unknown option "regexp", did you mean regex?`},
+		},
+		{
+			name: "duplicate recipe",
+			content: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Twice
+recipeList:
+  - org.openrewrite.java.format.BlankLines
+---
+type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Twice
+recipeList:
+  - org.openrewrite.java.format.TabsAndIndents
+`,
+			want: []string{"rewrite.yml:6:1: error: recipe com.example.Twice is declared more than once, first at rewrite.yml:1:1"},
+		},
+		{
+			name: "duplicate category",
+			content: `type: specs.openrewrite.org/v1beta/category
+packageName: com.example
+---
+type: specs.openrewrite.org/v1beta/category
+packageName: com.example
+`,
+			want: []string{"rewrite.yml:4:1: warning: category of package com.example is declared more than once"},
+		},
+		{
+			name: "mistyped key",
+			content: `type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Mistyped
+recipeList: org.openrewrite.java.format.BlankLines
+`,
+			want: []string{`rewrite.yml:3:13: error: recipeList must be a list, got "org.openrewrite.java.format.BlankLines"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateConfiguration(t, tt.content, tt.activeRecipes...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	styles := []string{
+		"org.openrewrite.java.style.BlankLinesStyle",
+		"org.openrewrite.java.style.ImportLayoutStyle",
+		"org.openrewrite.java.style.TabsAndIndentsStyle",
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"org.openrewrite.java.style.ImportLayoutStyl", ", did you mean org.openrewrite.java.style.ImportLayoutStyle?"},
+		{"org.openrewrite.java.style.blanklinesstyle", ", did you mean org.openrewrite.java.style.BlankLinesStyle?"},
+		{"org.openrewrite.java.style.ImportOrderStyle", ""},
+		{"org.openrewrite.java.style.WrappingStyle", ""},
+		{"org.openrewrite.java.style.BlankLinesStyle", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := didYouMean(tt.name, styles); got != tt.want {
+				t.Errorf("didYouMean(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}